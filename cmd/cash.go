@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// cashVoucher es un código de pago de cobranza tal como lo devuelve el plugin de Pagopar
+type cashVoucher struct {
+	CodigoPago      string `json:"codigo_pago"`
+	RedCobranza     string `json:"red_cobranza"`
+	HashPedido      string `json:"hash_pedido"`
+	NumeroPedido    string `json:"numero_pedido"`
+	Monto           string `json:"monto"`
+	FechaMaximaPago string `json:"fecha_maxima_pago"`
+}
+
+var cashCmd = &cobra.Command{
+	Use:   "cash",
+	Short: "Gestionar pagos en redes de cobranza de Pagopar (Aqui Pago, Pago Express, Infonet, Wepa)",
+	Long: `Lista los códigos de pago pendientes y simula su pago en la boca de cobranza.
+Requiere el emulador en ejecución ('start').`,
+}
+
+var listCashCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lista los códigos de pago pendientes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var response struct {
+			Vouchers []cashVoucher `json:"vouchers"`
+		}
+		if err := cashRequest(cmd, http.MethodGet, "/emulator/cash", &response); err != nil {
+			return err
+		}
+
+		if len(response.Vouchers) == 0 {
+			fmt.Println("No hay códigos de pago pendientes")
+			return nil
+		}
+		for _, voucher := range response.Vouchers {
+			fmt.Printf("  • %s - %s | Pedido %s | Gs. %s | Vence %s\n",
+				voucher.CodigoPago, voucher.RedCobranza, voucher.NumeroPedido, voucher.Monto, voucher.FechaMaximaPago)
+		}
+		return nil
+	},
+}
+
+var payCashCmd = &cobra.Command{
+	Use:   "pay [codigo|hash]",
+	Short: "Marca como pagado un código de pago y notifica al comercio",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var response struct {
+			Voucher cashVoucher `json:"voucher"`
+		}
+		if err := cashRequest(cmd, http.MethodPost, "/emulator/cash/"+url.PathEscape(args[0])+"/pay", &response); err != nil {
+			return err
+		}
+
+		fmt.Printf(" Código %s pagado en %s (pedido %s)\n", response.Voucher.CodigoPago, response.Voucher.RedCobranza, response.Voucher.NumeroPedido)
+		return nil
+	},
+}
+
+// cashRequest llama a la API del emulador de Pagopar y decodifica la respuesta
+func cashRequest(cmd *cobra.Command, method, path string, out interface{}) error {
+	baseURL, err := pagoparURL(cmd)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(method, strings.TrimRight(baseURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("no se pudo conectar con el emulador: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		var pagoparError struct {
+			Resultado string `json:"resultado"`
+		}
+		json.NewDecoder(response.Body).Decode(&pagoparError)
+		return fmt.Errorf("%s (HTTP %d)", pagoparError.Resultado, response.StatusCode)
+	}
+	return json.NewDecoder(response.Body).Decode(out)
+}
+
+// pagoparURL es la URL del plugin de Pagopar: la indicada con --pagopar-url o la que le
+// asigna 'start' con los mismos --port y --plugins
+func pagoparURL(cmd *cobra.Command) (string, error) {
+	if baseURL, _ := cmd.Flags().GetString("pagopar-url"); baseURL != "" {
+		return baseURL, nil
+	}
+
+	port, _ := cmd.Flags().GetInt("port")
+	pluginNames, _ := cmd.Flags().GetStringSlice("plugins")
+	for i, pluginName := range pluginNames {
+		if pluginName == "pagopar" {
+			return pluginURL(pluginPort(port, i)), nil
+		}
+	}
+	return "", fmt.Errorf("el plugin pagopar no está entre los plugins indicados (%s)", strings.Join(pluginNames, ", "))
+}
+
+func init() {
+	rootCmd.AddCommand(cashCmd)
+	cashCmd.PersistentFlags().IntP("port", "p", 8000, "Puerto principal con el que se inició el emulador ('start --port')")
+	cashCmd.PersistentFlags().StringSliceP("plugins", "P", []string{"bancard", "pagopar"}, "Plugins con los que se inició el emulador ('start --plugins')")
+	cashCmd.PersistentFlags().String("pagopar-url", "", "URL del plugin de Pagopar (por defecto se deriva de --port y --plugins)")
+	cashCmd.AddCommand(listCashCmd)
+	cashCmd.AddCommand(payCashCmd)
+}
@@ -7,11 +7,15 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"payment-emulator/internal/plugins"
 	"payment-emulator/internal/server"
+	"payment-emulator/internal/store"
+	"payment-emulator/internal/webhook"
 	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var startCmd = &cobra.Command{
@@ -29,24 +33,57 @@ func init() {
 	startCmd.Flags().IntP("port", "p", 8000, "Puerto principal del dashboard")
 	startCmd.Flags().StringSliceP("plugins", "P", []string{"bancard", "pagopar"}, "Plugins a cargar")
 	startCmd.Flags().BoolP("dashboard", "d", true, "Mostrar dashboard web")
+	startCmd.Flags().Bool("lenient-tokens", false, "Aceptar tokens con firma inválida (solo registrar advertencia)")
+	startCmd.Flags().String("data-dir", "", "Directorio para persistir transacciones (vacío = solo memoria)")
+	startCmd.Flags().Int("webhook-retries", webhook.DefaultConfig.MaxAttempts, "Intentos máximos por entrega de webhook")
+	startCmd.Flags().Duration("webhook-backoff", webhook.DefaultConfig.Backoff, "Espera inicial entre reintentos de webhook (se duplica en cada intento)")
+	startCmd.Flags().Duration("webhook-timeout", webhook.DefaultConfig.Timeout, "Timeout de cada intento de webhook")
 }
 
 func startServer(cmd *cobra.Command) {
 	port, _ := cmd.Flags().GetInt("port")
-	plugins, _ := cmd.Flags().GetStringSlice("plugins")
+	pluginNames, _ := cmd.Flags().GetStringSlice("plugins")
 	dashboard, _ := cmd.Flags().GetBool("dashboard")
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	lenientTokens, _ := cmd.Flags().GetBool("lenient-tokens")
+	webhookRetries, _ := cmd.Flags().GetInt("webhook-retries")
+	webhookBackoff, _ := cmd.Flags().GetDuration("webhook-backoff")
+	webhookTimeout, _ := cmd.Flags().GetDuration("webhook-timeout")
 
 	fmt.Printf("Iniciando PYment Dev Emulator...\n")
 	fmt.Printf("Dashboard: http://localhost:%d\n", port)
 	fmt.Printf("API Docs: http://localhost:%d/docs\n", port)
 
-	// Crear servidor principal
-	mainServer := server.NewMainServer(port, dashboard)
+	// Configurar persistencia de transacciones
+	if dataDir != "" {
+		backend, err := store.OpenJournal(dataDir)
+		if err != nil {
+			log.Fatalf("Error abriendo directorio de datos: %v", err)
+		}
+		if err := store.GetGlobalStore().SetBackend(backend); err != nil {
+			log.Fatalf("Error cargando transacciones: %v", err)
+		}
+		fmt.Printf("Datos: %s (%d transacciones)\n", backend.Path(), len(store.GetGlobalStore().List("")))
+	}
+
+	plugins.SetLenientTokens(lenientTokens)
+	if err := loadConfiguredMerchants(); err != nil {
+		log.Fatalf("Error leyendo comercios de la configuración: %v", err)
+	}
+
+	// Configurar entrega de webhooks
+	webhookConfig := webhook.DefaultConfig
+	webhookConfig.MaxAttempts = webhookRetries
+	webhookConfig.Backoff = webhookBackoff
+	webhookConfig.Timeout = webhookTimeout
+	webhook.GetGlobalDispatcher().Configure(webhookConfig)
 
 	// Cargar plugins
 	pluginServers := make([]*http.Server, 0)
-	for i, pluginName := range plugins {
-		pluginPort := port + i + 1
+	pluginURLs := make(map[string]string)
+	for i, pluginName := range pluginNames {
+		pluginPort := pluginPort(port, i)
+		pluginURLs[pluginName] = pluginURL(pluginPort)
 		pluginServer := server.NewPluginServer(pluginName, pluginPort)
 		pluginServers = append(pluginServers, pluginServer)
 
@@ -60,7 +97,8 @@ func startServer(cmd *cobra.Command) {
 		}(pluginServer)
 	}
 
-	// Iniciar servidor principal
+	// Crear e iniciar servidor principal
+	mainServer := server.NewMainServer(port, dashboard, pluginURLs)
 	go func() {
 		if err := mainServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Printf("Main server error: %v", err)
@@ -92,5 +130,35 @@ func startServer(cmd *cobra.Command) {
 		}
 	}
 
+	if err := store.GetGlobalStore().Close(); err != nil {
+		log.Printf("Store close error: %v", err)
+	}
+
 	fmt.Printf(" Servicios detenidos correctamente\n")
 }
+
+// loadConfiguredMerchants registra los comercios de la sección merchants.<plugin>
+// del archivo de configuración del emulador
+func loadConfiguredMerchants() error {
+	var merchants map[string][]plugins.Merchant
+	if err := viper.UnmarshalKey("merchants", &merchants); err != nil {
+		return err
+	}
+
+	for pluginName, list := range merchants {
+		plugins.AddMerchants(pluginName, list)
+		fmt.Printf("Comercios %s: %d desde la configuración\n", pluginName, len(list))
+	}
+	return nil
+}
+
+// pluginPort es el puerto del plugin en la posición index de --plugins: los plugins
+// escuchan en los puertos siguientes al del dashboard, en el orden en que se cargan
+func pluginPort(port, index int) int {
+	return port + index + 1
+}
+
+// pluginURL es la URL base local del plugin que escucha en pluginPort
+func pluginURL(pluginPort int) string {
+	return fmt.Sprintf("http://localhost:%d", pluginPort)
+}
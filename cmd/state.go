@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"payment-emulator/internal/store"
+
+	"github.com/spf13/cobra"
+)
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Gestionar el estado persistido de las transacciones",
+	Long: `Exporta, importa o reinicia las transacciones guardadas en el directorio de datos.
+Ejecutar con el emulador detenido y el mismo --data-dir usado en 'start'.`,
+}
+
+var exportStateCmd = &cobra.Command{
+	Use:   "export [archivo]",
+	Short: "Exporta las transacciones a un archivo JSON (o stdout)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStateStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		var out io.Writer = os.Stdout
+		if len(args) == 1 {
+			file, err := os.Create(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+
+		snapshot := s.Snapshot()
+		if err := store.WriteSnapshot(out, snapshot); err != nil {
+			return err
+		}
+
+		if len(args) == 1 {
+			fmt.Printf(" %d transacciones y %d tarjetas exportadas a %s\n", len(snapshot.Transactions), len(snapshot.Cards), args[0])
+		}
+		return nil
+	},
+}
+
+var importStateCmd = &cobra.Command{
+	Use:   "import [archivo]",
+	Short: "Importa transacciones desde un archivo JSON exportado",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		snapshot, err := store.ReadSnapshot(file)
+		if err != nil {
+			return err
+		}
+
+		s, err := openStateStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		merge, _ := cmd.Flags().GetBool("merge")
+		if !merge {
+			if err := s.Reset(); err != nil {
+				return err
+			}
+		}
+
+		if err := s.Restore(snapshot); err != nil {
+			return err
+		}
+
+		fmt.Printf(" %d transacciones y %d tarjetas importadas desde %s\n", len(snapshot.Transactions), len(snapshot.Cards), args[0])
+		return nil
+	},
+}
+
+var resetStateCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Elimina todas las transacciones persistidas",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := openStateStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		if err := s.Reset(); err != nil {
+			return err
+		}
+
+		fmt.Printf(" Estado reiniciado\n")
+		return nil
+	},
+}
+
+// openStateStore abre un store respaldado por el journal del directorio de datos
+func openStateStore(cmd *cobra.Command) (*store.Store, error) {
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	if dataDir == "" {
+		return nil, fmt.Errorf("--data-dir es obligatorio")
+	}
+
+	backend, err := store.OpenJournal(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := store.NewStore()
+	if err := s.SetBackend(backend); err != nil {
+		backend.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func init() {
+	rootCmd.AddCommand(stateCmd)
+	stateCmd.PersistentFlags().String("data-dir", "", "Directorio de datos del emulador")
+	stateCmd.AddCommand(exportStateCmd)
+	stateCmd.AddCommand(importStateCmd)
+	stateCmd.AddCommand(resetStateCmd)
+	importStateCmd.Flags().Bool("merge", false, "Agregar a las transacciones existentes en lugar de reemplazarlas")
+}
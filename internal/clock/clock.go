@@ -0,0 +1,91 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock es el reloj del emulador: la hora real desplazada por un offset ajustable, para
+// simular el paso del tiempo (vencimientos, ventanas de reversa) sin esperar
+type Clock struct {
+	mutex     sync.RWMutex
+	offset    time.Duration
+	listeners []func(now time.Time)
+}
+
+// NewClock crea un reloj sincronizado con la hora real
+func NewClock() *Clock {
+	return &Clock{}
+}
+
+// Now devuelve la hora actual del emulador
+func (c *Clock) Now() time.Time {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return time.Now().Add(c.offset)
+}
+
+// Offset devuelve cuánto se adelantó (o atrasó) el reloj respecto de la hora real
+func (c *Clock) Offset() time.Duration {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.offset
+}
+
+// Advance adelanta el reloj la duración indicada y devuelve la nueva hora
+func (c *Clock) Advance(d time.Duration) time.Time {
+	c.mutex.Lock()
+	c.offset += d
+	c.mutex.Unlock()
+	return c.changed()
+}
+
+// Set fija la hora del emulador
+func (c *Clock) Set(now time.Time) time.Time {
+	c.mutex.Lock()
+	c.offset = time.Until(now)
+	c.mutex.Unlock()
+	return c.changed()
+}
+
+// Reset vuelve a sincronizar el reloj con la hora real
+func (c *Clock) Reset() time.Time {
+	c.mutex.Lock()
+	c.offset = 0
+	c.mutex.Unlock()
+	return c.changed()
+}
+
+// OnChange registra una función que se ejecuta cada vez que se ajusta el reloj,
+// por ejemplo para procesar los vencimientos alcanzados
+func (c *Clock) OnChange(fn func(now time.Time)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.listeners = append(c.listeners, fn)
+}
+
+func (c *Clock) changed() time.Time {
+	c.mutex.RLock()
+	listeners := make([]func(time.Time), len(c.listeners))
+	copy(listeners, c.listeners)
+	now := time.Now().Add(c.offset)
+	c.mutex.RUnlock()
+
+	for _, fn := range listeners {
+		fn(now)
+	}
+	return now
+}
+
+// Global clock instance
+var globalClock = NewClock()
+
+// GetGlobalClock devuelve el reloj global del emulador
+func GetGlobalClock() *Clock {
+	return globalClock
+}
+
+// Now devuelve la hora actual del reloj global del emulador
+func Now() time.Time {
+	return globalClock.Now()
+}
@@ -0,0 +1,36 @@
+package plugins
+
+import (
+	"strings"
+	"unicode"
+)
+
+// JSONFieldPath convierte el namespace de un error de validación de binding
+// (BancardOrderRequest.Operation.ShopProcessID, PagoparOrderRequest.ComprasItems[0].Nombre)
+// en la ruta JSON del campo (operation.shop_process_id, compras_items[0].nombre)
+func JSONFieldPath(namespace string) string {
+	parts := strings.Split(namespace, ".")
+	if len(parts) > 1 {
+		parts = parts[1:]
+	}
+	for i, part := range parts {
+		parts[i] = snakeCase(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var builder strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Nueva palabra: después de una minúscula, o al final de una sigla (IDToken -> id_token)
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				builder.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
@@ -0,0 +1,59 @@
+package plugins
+
+import "strings"
+
+// InstallmentPlan representa un plan de cuotas habilitado para una marca o banco emisor de tarjeta
+type InstallmentPlan struct {
+	Brand        string  `yaml:"brand"`         // Marca de la tarjeta (vacío = todas)
+	Issuer       string  `yaml:"issuer"`        // Banco emisor (vacío = todos)
+	Installments []int   `yaml:"installments"`  // Cantidades de cuotas permitidas
+	MinAmount    float64 `yaml:"min_amount"`    // Monto mínimo de la compra para financiar
+	InterestRate float64 `yaml:"interest_rate"` // Interés total en porcentaje (0 = sin intereses)
+}
+
+// Matches indica si el plan aplica a una tarjeta de la marca y emisor indicados
+func (p *InstallmentPlan) Matches(brand, issuer string) bool {
+	return matchesCard(p.Brand, p.Issuer, brand, issuer)
+}
+
+// Allows indica si el plan permite pagar en la cantidad de cuotas indicada
+func (p *InstallmentPlan) Allows(installments int) bool {
+	for _, allowed := range p.Installments {
+		if allowed == installments {
+			return true
+		}
+	}
+	return false
+}
+
+// Promotion representa un código de promoción con descuento sobre el monto de la compra
+type Promotion struct {
+	Code            string  `yaml:"code"`
+	Description     string  `yaml:"description"`
+	DiscountPercent float64 `yaml:"discount_percent"`
+	Brand           string  `yaml:"brand"`      // Marca de la tarjeta (vacío = todas)
+	Issuer          string  `yaml:"issuer"`     // Banco emisor (vacío = todos)
+	MinAmount       float64 `yaml:"min_amount"` // Monto mínimo de la compra
+}
+
+// Matches indica si la promoción aplica a una tarjeta de la marca y emisor indicados
+func (p *Promotion) Matches(brand, issuer string) bool {
+	return matchesCard(p.Brand, p.Issuer, brand, issuer)
+}
+
+// FindPromotion busca una promoción por código (sin distinguir mayúsculas)
+func (p *Plugin) FindPromotion(code string) (*Promotion, bool) {
+	for i := range p.Promotions {
+		if strings.EqualFold(p.Promotions[i].Code, code) {
+			return &p.Promotions[i], true
+		}
+	}
+	return nil, false
+}
+
+func matchesCard(wantBrand, wantIssuer, brand, issuer string) bool {
+	if wantBrand != "" && !strings.EqualFold(wantBrand, brand) {
+		return false
+	}
+	return wantIssuer == "" || strings.EqualFold(wantIssuer, issuer)
+}
@@ -0,0 +1,75 @@
+package plugins
+
+import "strings"
+
+// Merchant representa un comercio configurado en un plugin
+type Merchant struct {
+	Name            string   `yaml:"name" mapstructure:"name"`
+	PublicKey       string   `yaml:"public_key" mapstructure:"public_key"`
+	PrivateKey      string   `yaml:"private_key" mapstructure:"private_key"`
+	ConfirmationURL string   `yaml:"confirmation_url" mapstructure:"confirmation_url"`
+	WebhookURL      string   `yaml:"webhook_url" mapstructure:"webhook_url"`
+	ResultURL       string   `yaml:"result_url" mapstructure:"result_url"` // Admite el marcador ($hash)
+	CancelURL       string   `yaml:"cancel_url" mapstructure:"cancel_url"` // Admite el marcador ($hash)
+	Currencies      []string `yaml:"currencies" mapstructure:"currencies"` // Monedas habilitadas (vacío = todas)
+}
+
+// AllowsCurrency indica si el comercio puede operar con la moneda indicada
+func (m *Merchant) AllowsCurrency(currency string) bool {
+	if len(m.Currencies) == 0 {
+		return true
+	}
+	for _, allowed := range m.Currencies {
+		if strings.EqualFold(allowed, currency) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindMerchant busca un comercio configurado por su clave pública
+func (p *Plugin) FindMerchant(publicKey string) (*Merchant, bool) {
+	for i := range p.Merchants {
+		if p.Merchants[i].PublicKey == publicKey {
+			return &p.Merchants[i], true
+		}
+	}
+	return nil, false
+}
+
+// extraMerchants contiene los comercios definidos en la configuración del emulador, por plugin
+var extraMerchants = map[string][]Merchant{}
+
+// AddMerchants registra comercios adicionales para un plugin. Se agregan a los de
+// plugins/<name>/config.yaml al cargar el plugin y, ante claves públicas repetidas,
+// tienen prioridad sobre ellos.
+func AddMerchants(pluginName string, merchants []Merchant) {
+	extraMerchants[pluginName] = append(extraMerchants[pluginName], merchants...)
+}
+
+// withMerchants agrega al plugin los comercios registrados con AddMerchants
+func withMerchants(plugin *Plugin, pluginName string) *Plugin {
+	extra := extraMerchants[pluginName]
+	if len(extra) == 0 {
+		return plugin
+	}
+
+	merchants := make([]Merchant, 0, len(extra)+len(plugin.Merchants))
+	merchants = append(merchants, extra...)
+	merchants = append(merchants, plugin.Merchants...)
+	plugin.Merchants = merchants
+	return plugin
+}
+
+// lenientTokens habilita el modo permisivo de tokens para todos los plugins
+var lenientTokens bool
+
+// SetLenientTokens habilita o deshabilita globalmente el modo permisivo de tokens
+func SetLenientTokens(lenient bool) {
+	lenientTokens = lenient
+}
+
+// IsLenient indica si el plugin debe aceptar tokens que no coinciden con la firma esperada
+func (p *Plugin) IsLenient() bool {
+	return lenientTokens || p.LenientTokens
+}
@@ -4,17 +4,23 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Plugin struct {
-	Name        string  `yaml:"name"`
-	Description string  `yaml:"description"`
-	Port        int     `yaml:"port"`
-	Type        string  `yaml:"type"` // "iframe" o "redirección"
-	Enabled     bool    `yaml:"enabled"`
-	Routes      []Route `yaml:"routes"`
+	Name             string            `yaml:"name"`
+	Description      string            `yaml:"description"`
+	Port             int               `yaml:"port"`
+	Type             string            `yaml:"type"` // "iframe" o "redirección"
+	Enabled          bool              `yaml:"enabled"`
+	Routes           []Route           `yaml:"routes"`
+	Merchants        []Merchant        `yaml:"merchants"`
+	TestCards        []TestCard        `yaml:"test_cards"`        // Tarjetas de prueba con resultado determinístico
+	InstallmentPlans []InstallmentPlan `yaml:"installment_plans"` // Planes de cuotas por marca o emisor
+	Promotions       []Promotion       `yaml:"promotions"`        // Códigos de promoción con descuento
+	LenientTokens    bool              `yaml:"lenient_tokens"`    // Acepta tokens inválidos (solo registra una advertencia)
 }
 
 type Route struct {
@@ -32,14 +38,17 @@ func LoadPlugin(name string) (*Plugin, error) {
 	}
 
 	var plugin Plugin
-	err = yaml.Unmarshal(data, &plugin)
-	return &plugin, err
+	if err := yaml.Unmarshal(data, &plugin); err != nil {
+		return nil, err
+	}
+	return withMerchants(&plugin, name), nil
 }
 
 func GetDefaultPlugin(name string, port int) *Plugin {
+	var plugin *Plugin
 	switch name {
 	case "bancard":
-		return &Plugin{
+		plugin = &Plugin{
 			Name:        "Bancard VPOS",
 			Description: "Emulador de Bancard VPOS",
 			Port:        port,
@@ -48,10 +57,44 @@ func GetDefaultPlugin(name string, port int) *Plugin {
 			Routes: []Route{
 				{Path: "/vpos/api/0.3/single_buy", Method: "POST", ResponseType: "redirect"},
 				{Path: "/vpos/api/0.3/confirmation", Method: "POST", ResponseType: "json"},
+				{Path: "/vpos/api/0.3/single_buy/confirmations", Method: "POST", ResponseType: "json"},
+				{Path: "/vpos/api/0.3/single_buy/rollback", Method: "POST", ResponseType: "json"},
+				{Path: "/vpos/api/0.3/refund", Method: "POST", ResponseType: "json"},
+				{Path: "/vpos/api/0.3/preauthorizations/confirm", Method: "POST", ResponseType: "json"},
+				{Path: "/vpos/api/0.3/preauthorizations/rollback", Method: "POST", ResponseType: "json"},
+				{Path: "/vpos/api/0.3/cards/new", Method: "POST", ResponseType: "json"},
+				{Path: "/vpos/api/0.3/users/:user_id/cards", Method: "POST", ResponseType: "json"},
+				{Path: "/vpos/api/0.3/users/:user_id/cards", Method: "DELETE", ResponseType: "json"},
+				{Path: "/vpos/api/0.3/charge", Method: "POST", ResponseType: "json"},
+				{Path: "/external-commerce/api/0.1/commerces/:commerce_code/branches/:branch_code/selling/generate-qr-express", Method: "POST", ResponseType: "json"},
+				{Path: "/external-commerce/api/0.1/commerces/:commerce_code/branches/:branch_code/selling/payments/:hook_alias", Method: "GET", ResponseType: "json"},
+				{Path: "/external-commerce/api/0.1/commerces/:commerce_code/branches/:branch_code/selling/payments/revert/:hook_alias", Method: "DELETE", ResponseType: "json"},
+			},
+			Merchants: []Merchant{
+				{Name: "Comercio de Prueba", PublicKey: "pk_test_bancard", PrivateKey: "sk_test_bancard"},
+			},
+			// Mismos catálogos que plugins/bancard/config.yaml
+			TestCards: []TestCard{
+				{Number: "4111111111111111", ResponseCode: "00", Description: "Transacción aprobada", Issuer: "Banco Itaú"},
+				{Number: "5555555555554444", ResponseCode: "00", Description: "Transacción aprobada", Issuer: "Banco Continental"},
+				{Number: "378282246310005", ResponseCode: "00", Description: "Transacción aprobada"},
+				{Number: "4000000000000051", ResponseCode: "51", Description: "Fondos insuficientes"},
+				{Number: "4000000000000705", ResponseCode: "05", Description: "No aprobada, contacte a su banco emisor"},
+				{Number: "4000000000000754", ResponseCode: "54", Description: "Tarjeta vencida"},
+				{Number: "4000000000000291", ResponseCode: "91", Description: "Emisor no disponible", Timeout: true, Delay: 3 * time.Second},
+			},
+			InstallmentPlans: []InstallmentPlan{
+				{Brand: "VISA", Installments: []int{3, 6, 12}, MinAmount: 300000},
+				{Brand: "MASTERCARD", Installments: []int{3, 6}, MinAmount: 300000},
+				{Issuer: "Banco Itaú", Installments: []int{18, 24}, MinAmount: 1000000, InterestRate: 15},
+			},
+			Promotions: []Promotion{
+				{Code: "ITAU20", Description: "20% de descuento con tarjetas Itaú", DiscountPercent: 20, Issuer: "Banco Itaú"},
+				{Code: "VISA10", Description: "10% de descuento con tarjetas Visa", DiscountPercent: 10, Brand: "VISA"},
 			},
 		}
 	case "pagopar":
-		return &Plugin{
+		plugin = &Plugin{
 			Name:        "Pagopar",
 			Description: "Emulador de Pagopar",
 			Port:        port,
@@ -62,10 +105,14 @@ func GetDefaultPlugin(name string, port int) *Plugin {
 				{Path: "/api/forma-pago/1.1/traer", Method: "POST", ResponseType: "json"},
 				{Path: "/api/pedidos/1.1/traer", Method: "POST", ResponseType: "json"},
 				{Path: "/pagos/:hash", Method: "GET", ResponseType: "html"},
+				{Path: "/pagos/:hash/comprobante", Method: "GET", ResponseType: "html"},
+			},
+			Merchants: []Merchant{
+				{Name: "Comercio de Prueba", PublicKey: "pk_test_pagopar", PrivateKey: "sk_test_pagopar", Currencies: []string{"PYG"}},
 			},
 		}
 	default:
-		return &Plugin{
+		plugin = &Plugin{
 			Name:        name,
 			Description: fmt.Sprintf("Plugin personalizado %s", name),
 			Port:        port,
@@ -76,6 +123,7 @@ func GetDefaultPlugin(name string, port int) *Plugin {
 			},
 		}
 	}
+	return withMerchants(plugin, name)
 }
 
 func GetAvailablePlugins() []Plugin {
@@ -0,0 +1,27 @@
+package plugins
+
+import (
+	"strings"
+	"time"
+)
+
+// TestCard representa una tarjeta de prueba que fuerza un resultado en el checkout
+type TestCard struct {
+	Number       string        `yaml:"number"`
+	ResponseCode string        `yaml:"response_code"` // "00" aprueba, cualquier otro código rechaza
+	Description  string        `yaml:"description"`
+	Issuer       string        `yaml:"issuer"`  // Banco emisor (para planes de cuotas y promociones)
+	Timeout      bool          `yaml:"timeout"` // El procesador no responde: la transacción queda pendiente
+	Delay        time.Duration `yaml:"delay"`   // Espera antes de responder (ej. "10s")
+}
+
+// FindTestCard busca una tarjeta de prueba por su número (se ignoran espacios y guiones)
+func (p *Plugin) FindTestCard(number string) (*TestCard, bool) {
+	normalized := strings.NewReplacer(" ", "", "-", "").Replace(number)
+	for i := range p.TestCards {
+		if p.TestCards[i].Number == normalized {
+			return &p.TestCards[i], true
+		}
+	}
+	return nil, false
+}
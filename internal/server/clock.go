@@ -0,0 +1,81 @@
+package server
+
+import (
+	"net/http"
+	"payment-emulator/internal/clock"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClockAdjustRequest es el cuerpo para adelantar o fijar el reloj del emulador
+type ClockAdjustRequest struct {
+	Duration string `json:"duration"` // Duración de Go, por ejemplo "49h" o "30m"
+	Time     string `json:"time"`     // Hora absoluta en RFC3339
+}
+
+// setupClockRoutes configura la API del reloj del emulador
+func setupClockRoutes(r *gin.Engine) {
+	api := r.Group("/emulator/api/clock")
+	{
+		api.GET("", handleGetClock)
+		api.POST("/advance", handleAdvanceClock)
+		api.POST("/set", handleSetClock)
+		api.POST("/reset", handleResetClock)
+	}
+}
+
+// handleGetClock devuelve la hora actual del emulador y su desfase respecto de la hora real
+func handleGetClock(c *gin.Context) {
+	c.JSON(http.StatusOK, clockStatus())
+}
+
+// handleAdvanceClock adelanta el reloj; los vencimientos alcanzados se procesan antes de responder
+func handleAdvanceClock(c *gin.Context) {
+	var request ClockAdjustRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos JSON inválidos: " + err.Error()})
+		return
+	}
+
+	duration, err := time.ParseDuration(request.Duration)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "duration inválida, se espera por ejemplo \"49h\" o \"30m\""})
+		return
+	}
+
+	clock.GetGlobalClock().Advance(duration)
+	c.JSON(http.StatusOK, clockStatus())
+}
+
+// handleSetClock fija la hora del emulador
+func handleSetClock(c *gin.Context) {
+	var request ClockAdjustRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos JSON inválidos: " + err.Error()})
+		return
+	}
+
+	now, err := time.Parse(time.RFC3339, request.Time)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "time inválido, se espera RFC3339 (2006-01-02T15:04:05-03:00)"})
+		return
+	}
+
+	clock.GetGlobalClock().Set(now)
+	c.JSON(http.StatusOK, clockStatus())
+}
+
+// handleResetClock vuelve a sincronizar el reloj del emulador con la hora real
+func handleResetClock(c *gin.Context) {
+	clock.GetGlobalClock().Reset()
+	c.JSON(http.StatusOK, clockStatus())
+}
+
+func clockStatus() gin.H {
+	emulatorClock := clock.GetGlobalClock()
+	return gin.H{
+		"now":    emulatorClock.Now().Format(time.RFC3339),
+		"offset": emulatorClock.Offset().String(),
+	}
+}
@@ -3,11 +3,20 @@ package server
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+	"payment-emulator/internal/webhook"
+	"sort"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
-func NewMainServer(port int, dashboard bool) *http.Server {
+// dashboardWebhookLimit es la cantidad de entregas de webhooks mostradas en el dashboard
+const dashboardWebhookLimit = 20
+
+// NewMainServer crea el servidor del dashboard. pluginURLs son las URLs base de los plugins
+// iniciados, por nombre, para las acciones del dashboard que llaman a un plugin.
+func NewMainServer(port int, dashboard bool, pluginURLs map[string]string) *http.Server {
 	if !gin.IsDebugging() {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -31,9 +40,17 @@ func NewMainServer(port int, dashboard bool) *http.Server {
 
 	// Rutas principales
 	r.GET("/", func(c *gin.Context) {
+		deliveries := webhook.GetGlobalDispatcher().List()
+		if len(deliveries) > dashboardWebhookLimit {
+			deliveries = deliveries[:dashboardWebhookLimit]
+		}
+
 		c.HTML(http.StatusOK, "dashboard.html", gin.H{
-			"title": "Payment Emulator Dashboard",
-			"ports": []int{8001, 8002}, // Puertos de plugins
+			"title":    "Payment Emulator Dashboard",
+			"plugins":  pluginStatus(pluginURLs),
+			"webhooks": deliveries,
+			"clock":    clockStatus(),
+			"pagopar":  pluginURLs["pagopar"],
 		})
 	})
 
@@ -46,15 +63,17 @@ func NewMainServer(port int, dashboard bool) *http.Server {
 
 	// API para obtener estado de plugins
 	r.GET("/api/plugins", func(c *gin.Context) {
-		// TODO: Implementar lógica real
 		c.JSON(http.StatusOK, gin.H{
-			"plugins": []gin.H{
-				{"name": "bancard", "port": 8001, "status": "running"},
-				{"name": "pagopar", "port": 8002, "status": "running"},
-			},
+			"plugins": pluginStatus(pluginURLs),
 		})
 	})
 
+	// API del log de webhooks
+	setupWebhookRoutes(r)
+
+	// API del reloj del emulador
+	setupClockRoutes(r)
+
 	// Cargar templates HTML embebidos
 	loadTemplates(r)
 
@@ -63,3 +82,22 @@ func NewMainServer(port int, dashboard bool) *http.Server {
 		Handler: r,
 	}
 }
+
+// pluginStatus describe los plugins iniciados, ordenados por nombre, con el puerto de su URL
+func pluginStatus(pluginURLs map[string]string) []gin.H {
+	names := make([]string, 0, len(pluginURLs))
+	for name := range pluginURLs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	status := make([]gin.H, 0, len(names))
+	for _, name := range names {
+		port := 0
+		if parsed, err := url.Parse(pluginURLs[name]); err == nil {
+			port, _ = strconv.Atoi(parsed.Port())
+		}
+		status = append(status, gin.H{"name": name, "url": pluginURLs[name], "port": port, "status": "running"})
+	}
+	return status
+}
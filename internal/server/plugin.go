@@ -2,6 +2,7 @@ package server
 
 import (
 	"fmt"
+	"html/template"
 	"net/http"
 	"payment-emulator/internal/plugins"
 
@@ -23,7 +24,7 @@ func NewPluginServer(pluginName string, port int) *http.Server {
 	// CORS middleware
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
 		c.Header("Access-Control-Allow-Headers", "Content-Type")
 
 		if c.Request.Method == "OPTIONS" {
@@ -35,10 +36,10 @@ func NewPluginServer(pluginName string, port int) *http.Server {
 	})
 
 	// Cargar templates HTML embebidos PRIMERO
-	loadTemplates(r)
+	templ := loadTemplates(r)
 
 	// Cargar y configurar el plugin específico
-	if err := setupPlugin(r, pluginName, port); err != nil {
+	if err := setupPlugin(r, templ, pluginName, port); err != nil {
 		fmt.Printf("Error setting up plugin '%s': %v\n", pluginName, err)
 		// Continuar con configuración básica si hay error
 		setupFallbackRoutes(r, pluginName, port)
@@ -51,7 +52,7 @@ func NewPluginServer(pluginName string, port int) *http.Server {
 }
 
 // setupPlugin configura un plugin específico usando el registry
-func setupPlugin(r *gin.Engine, pluginName string, port int) error {
+func setupPlugin(r *gin.Engine, templ *template.Template, pluginName string, port int) error {
 	registry := plugins.GetGlobalRegistry()
 
 	// Intentar obtener plugin del registry
@@ -79,7 +80,7 @@ func setupPlugin(r *gin.Engine, pluginName string, port int) error {
 	plugin.SetupRoutes(r)
 
 	// Cargar templates específicos del plugin
-	loadPluginTemplates(r, plugin)
+	loadPluginTemplates(r, templ, plugin)
 
 	// Ruta de documentación del plugin
 	r.GET("/", func(c *gin.Context) {
@@ -98,15 +99,13 @@ func setupPlugin(r *gin.Engine, pluginName string, port int) error {
 	return nil
 }
 
-// loadPluginTemplates carga los templates específicos de un plugin
-func loadPluginTemplates(r *gin.Engine, plugin plugins.PaymentPlugin) {
-	templates := plugin.GetTemplates()
-
-	// Por ahora, los templates están incluidos en los templates generales
-	// En el futuro se pueden cargar dinámicamente aquí
-	for templateName := range templates {
-		fmt.Printf("Template '%s' available for plugin '%s'\n", templateName, plugin.GetName())
+// loadPluginTemplates registra los templates específicos de un plugin junto a los comunes
+func loadPluginTemplates(r *gin.Engine, templ *template.Template, plugin plugins.PaymentPlugin) {
+	for name, content := range plugin.GetTemplates() {
+		templ = template.Must(templ.New(name).Parse(content))
 	}
+
+	r.SetHTMLTemplate(templ)
 }
 
 // setupFallbackRoutes configura rutas básicas cuando falla la carga del plugin
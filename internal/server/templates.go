@@ -6,7 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func loadTemplates(r *gin.Engine) {
+func loadTemplates(r *gin.Engine) *template.Template {
 	// Templates comunes del sistema (no específicos de plugins)
 	dashboardHTML := `<!DOCTYPE html>
 <html>
@@ -22,6 +22,12 @@ func loadTemplates(r *gin.Engine) {
         a:hover { text-decoration: underline; }
         h1 { color: #333; margin-bottom: 30px; }
         .header { text-align: center; margin-bottom: 40px; }
+        .webhooks { width: 100%; border-collapse: collapse; font-size: 14px; }
+        .webhooks th, .webhooks td { padding: 8px; border-bottom: 1px solid #eee; text-align: left; }
+        .webhooks .url { font-family: monospace; word-break: break-all; }
+        .delivery-delivered { color: #28a745; font-weight: bold; }
+        .delivery-failed { color: #dc3545; font-weight: bold; }
+        .delivery-retrying, .delivery-pending { color: #ffc107; font-weight: bold; }
     </style>
 </head>
 <body>
@@ -32,20 +38,129 @@ func loadTemplates(r *gin.Engine) {
         </div>
         
         <h2>Servicios Activos</h2>
-        {{range .ports}}
+        {{range .plugins}}
         <div class="plugin">
-            <h3>Plugin en Puerto {{.}}</h3>
+            <h3>Plugin {{.name}} en Puerto {{.port}}</h3>
             <p class="status">● Estado: Activo</p>
-            <p><a href="http://localhost:{{.}}" target="_blank">er Documentación</a></p>
+            <p><a href="{{.url}}" target="_blank">er Documentación</a></p>
         </div>
         {{end}}
         
+        <h2>Reloj del Emulador</h2>
+        <div class="plugin">
+            <p><strong>Hora:</strong> {{.clock.now}}{{if ne .clock.offset "0s"}} (desfase {{.clock.offset}}){{end}}</p>
+            <p>
+                <a href="#" onclick="advanceClock('1h'); return false;">+1 hora</a> |
+                <a href="#" onclick="advanceClock('24h'); return false;">+1 día</a> |
+                <a href="#" onclick="advanceClock('49h'); return false;">+49 horas</a> |
+                <a href="#" onclick="resetClock(); return false;">Hora real</a>
+            </p>
+        </div>
+
+        {{if .pagopar}}
+        <h2>Cobranzas Pendientes (Pagopar)</h2>
+        <div id="cash-vouchers" class="plugin"><p>Cargando códigos de pago...</p></div>
+        {{end}}
+
+        <h2>Webhooks Enviados</h2>
+        {{if .webhooks}}
+        <table class="webhooks">
+            <tr><th>Fecha</th><th>Evento</th><th>URL</th><th>Estado</th><th>Intentos</th><th></th></tr>
+            {{range .webhooks}}
+            <tr>
+                <td>{{.CreatedAt.Format "15:04:05"}}</td>
+                <td>{{.Event}}</td>
+                <td class="url">{{.URL}}</td>
+                <td class="delivery-{{.Status}}">{{.Status}}{{with .LastAttempt}}{{if .StatusCode}} ({{.StatusCode}}){{end}}{{end}}</td>
+                <td>{{len .Attempts}}</td>
+                <td>
+                    <a href="/emulator/api/webhooks/{{.ID}}" target="_blank">Log</a>
+                    {{if or (eq .Status "delivered") (eq .Status "failed")}}
+                    | <a href="#" onclick="redeliver('{{.ID}}'); return false;">Reenviar</a>
+                    {{end}}
+                </td>
+            </tr>
+            {{end}}
+        </table>
+        {{else}}
+        <div class="plugin"><p>Todavía no se enviaron webhooks.</p></div>
+        {{end}}
+        
         <h2>Documentación</h2>
         <div class="plugin">
             <p><a href="/api/plugins">Ver API de Plugins</a></p>
+            <p><a href="/emulator/api/webhooks">Ver Log de Webhooks</a></p>
+            <p><a href="/emulator/api/clock">Ver Reloj del Emulador</a></p>
             <p><a href="/health">Health Check</a></p>
         </div>
     </div>
+
+    <script>
+        // Códigos de pago de Aqui Pago, Pago Express, Infonet y Wepa emitidos por el plugin de Pagopar
+        const pagoparURL = '{{.pagopar}}';
+
+        function loadCashVouchers() {
+            const container = document.getElementById('cash-vouchers');
+            if (!container) {
+                return;
+            }
+            fetch(pagoparURL + '/emulator/cash')
+                .then(response => response.json())
+                .then(data => {
+                    if (!data.vouchers || data.vouchers.length === 0) {
+                        container.innerHTML = '<p>No hay códigos de pago pendientes.</p>';
+                        return;
+                    }
+                    let html = '<table class="webhooks"><tr><th>Código</th><th>Red</th><th>Pedido</th><th>Monto</th><th>Vence</th><th></th></tr>';
+                    data.vouchers.forEach(v => {
+                        html += '<tr><td>' + v.codigo_pago + '</td><td>' + v.red_cobranza + '</td><td>' + v.numero_pedido +
+                            '</td><td>Gs. ' + v.monto + '</td><td>' + v.fecha_maxima_pago +
+                            '</td><td><a href="#" onclick="payCashVoucher(\'' + v.codigo_pago + '\'); return false;">Marcar pagado</a></td></tr>';
+                    });
+                    container.innerHTML = html + '</table>';
+                })
+                .catch(() => {
+                    container.innerHTML = '<p>El plugin de Pagopar no está disponible.</p>';
+                });
+        }
+
+        function payCashVoucher(codigo) {
+            fetch(pagoparURL + '/emulator/cash/' + codigo + '/pay', { method: 'POST' })
+                .then(response => response.json())
+                .then(data => {
+                    if (data.respuesta === false) {
+                        alert(data.resultado);
+                    }
+                    setTimeout(() => window.location.reload(), 1000);
+                });
+        }
+
+        loadCashVouchers();
+
+        function advanceClock(duration) {
+            fetch('/emulator/api/clock/advance', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ duration: duration })
+            }).then(() => window.location.reload());
+        }
+
+        function resetClock() {
+            fetch('/emulator/api/clock/reset', { method: 'POST' })
+                .then(() => window.location.reload());
+        }
+
+        function redeliver(id) {
+            fetch('/emulator/api/webhooks/' + id + '/redeliver', { method: 'POST' })
+                .then(response => response.json())
+                .then(data => {
+                    if (data.error) {
+                        alert(data.error);
+                    }
+                    setTimeout(() => window.location.reload(), 1000);
+                });
+        }
+    </script>
 </body>
 </html>`
 
@@ -81,6 +196,30 @@ func loadTemplates(r *gin.Engine) {
         </div>
         {{end}}
         
+        {{if .plugin.Merchants}}
+        <h2>Comercios Configurados</h2>
+        {{range .plugin.Merchants}}
+        <div class="route">
+            <h3>{{.Name}}</h3>
+            <p><strong>Clave pública:</strong> <span class="path">{{.PublicKey}}</span></p>
+            {{if .Currencies}}<p><strong>Monedas:</strong> {{range $i, $c := .Currencies}}{{if $i}}, {{end}}{{$c}}{{end}}</p>{{end}}
+            {{if .ConfirmationURL}}<p><strong>URL de confirmación:</strong> {{.ConfirmationURL}}</p>{{end}}
+            {{if .WebhookURL}}<p><strong>URL de webhook:</strong> {{.WebhookURL}}</p>{{end}}
+            {{if .ResultURL}}<p><strong>URL de resultado:</strong> {{.ResultURL}}</p>{{end}}
+            {{if .CancelURL}}<p><strong>URL de cancelación:</strong> {{.CancelURL}}</p>{{end}}
+        </div>
+        {{end}}
+        {{end}}
+        
+        {{if .plugin.TestCards}}
+        <h2>Tarjetas de Prueba</h2>
+        <div class="route">
+            {{range .plugin.TestCards}}
+            <p><span class="path">{{.Number}}</span> <strong>{{.ResponseCode}}</strong> {{.Description}}{{if .Timeout}} (timeout){{end}}</p>
+            {{end}}
+        </div>
+        {{end}}
+        
         <h2>Ejemplo de Uso</h2>
         <div class="route">
             <p>Para probar este plugin, realiza una petición POST a:</p>
@@ -248,258 +387,6 @@ func loadTemplates(r *gin.Engine) {
 	templ = template.Must(templ.New("iframe_emulator.html").Parse(iframeEmulatorHTML))
 	templ = template.Must(templ.New("popup_emulator.html").Parse(popupEmulatorHTML))
 
-	// Agregar templates específicos de plugins
-	loadPluginSpecificTemplates(templ)
-
 	r.SetHTMLTemplate(templ)
-}
-
-// loadPluginSpecificTemplates carga templates específicos de cada plugin
-func loadPluginSpecificTemplates(templ *template.Template) {
-	// Templates de Pagopar
-	pagoparTemplates := map[string]string{
-		"pagopar_checkout.html": `<!DOCTYPE html>
-<html>
-<head>
-    <title>Pagopar - Checkout</title>
-    <meta charset="utf-8">
-    <style>
-        body { font-family: Arial, sans-serif; margin: 40px; background: #f5f5f5; }
-        .container { max-width: 600px; margin: 0 auto; background: white; padding: 30px; border-radius: 8px; box-shadow: 0 2px 10px rgba(0,0,0,0.1); }
-        .method { background: #f8f9fa; padding: 15px; margin: 10px 0; border-radius: 6px; border: 1px solid #ddd; cursor: pointer; }
-        .method:hover { background: #e9ecef; }
-        .selected { border-color: #007bff; background: #e7f3ff; }
-        button { background: #007bff; color: white; border: none; padding: 12px 24px; border-radius: 4px; cursor: pointer; font-size: 16px; }
-        button:hover { background: #0056b3; }
-        .info { background: #d4edda; padding: 15px; border-radius: 4px; margin: 20px 0; border: 1px solid #c3e6cb; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <h1>Pagopar - Checkout</h1>
-        <div class="info">
-            <p><strong>Hash del pedido:</strong> {{.hash}}</p>
-            <p><strong>Forma de pago seleccionada:</strong> {{.formaPago}}</p>
-        </div>
-        
-        <h2>Seleccionar método de pago:</h2>
-        {{range .methods}}
-        <div class="method" onclick="selectMethod('{{.FormaPago}}')">
-            <h3>{{.Titulo}}</h3>
-            <p>{{.Descripcion}}</p>
-            <small>Comisión: {{.PorcentajeComision}}% - Mínimo: Gs. {{.MontoMinimo}}</small>
-        </div>
-        {{end}}
-        
-        <div style="margin-top: 30px;">
-            <button onclick="processPayment('success')">Simular Pago Exitoso</button>
-            <button onclick="processPayment('error')" style="background: #dc3545; margin-left: 10px;">Simular Error</button>
-            <button onclick="processPayment('pending')" style="background: #ffc107; color: black; margin-left: 10px;">Simular Pendiente</button>
-        </div>
-        
-        <div style="margin-top: 20px; border-top: 1px solid #ddd; padding-top: 20px;">
-            <h3>Simular Flujo Completo</h3>
-            <p><small>Esto simulará el flujo completo con redirect a tu aplicación</small></p>
-            <button onclick="processPaymentWithRedirect('success')" style="background: #28a745;">Pagar y Redirigir (Éxito)</button>
-            <button onclick="processPaymentWithRedirect('error')" style="background: #dc3545; margin-left: 10px;">Pagar y Redirigir (Error)</button>
-        </div>
-    </div>
-    
-    <script>
-        function selectMethod(id) {
-            document.querySelectorAll('.method').forEach(m => m.classList.remove('selected'));
-            event.target.closest('.method').classList.add('selected');
-        }
-        
-        function processPayment(result) {
-            alert('Simulando resultado: ' + result);
-            fetch('/emulator/webhook/{{.hash}}?result=' + result, {
-                method: 'POST'
-            }).then(response => response.json())
-            .then(data => {
-                console.log('Webhook simulado:', data);
-                window.location.href = '/emulator/result?hash={{.hash}}&result=' + result;
-            });
-        }
-        
-        function processPaymentWithRedirect(result) {
-            alert('Procesando pago y redirigiendo a la aplicación...');
-            setTimeout(() => {
-                window.location.href = '/resultado/{{.hash}}';
-            }, 2000);
-        }
-    </script>
-</body>
-</html>`,
-
-		"pagopar_result.html": `<!DOCTYPE html>
-<html>
-<head>
-    <title>Pagopar - Resultado</title>
-    <meta charset="utf-8">
-    <style>
-        body { font-family: Arial, sans-serif; margin: 40px; background: #f5f5f5; text-align: center; }
-        .container { max-width: 500px; margin: 0 auto; background: white; padding: 40px; border-radius: 8px; box-shadow: 0 2px 10px rgba(0,0,0,0.1); }
-        .status { font-size: 48px; margin: 20px 0; }
-        .message { font-size: 18px; margin: 20px 0; font-weight: bold; }
-        .hash { background: #f8f9fa; padding: 15px; border-radius: 4px; font-family: monospace; word-break: break-all; margin: 20px 0; }
-        button { background: #007bff; color: white; border: none; padding: 12px 24px; border-radius: 4px; cursor: pointer; font-size: 16px; margin: 10px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        {{if eq .result "success"}}
-        <div class="status">Exito</div>
-        <div class="message" style="color: #28a745;">Pago Exitoso</div>
-        {{else if eq .result "error"}}
-        <div class="status">Error</div>
-        <div class="message" style="color: #dc3545;">Error en el Pago</div>
-        {{else}}
-        <div class="status">Pendiente</div>
-        <div class="message" style="color: #ffc107;">Pago Pendiente</div>
-        {{end}}
-        
-        <div class="hash">
-            <strong>Hash:</strong><br>{{.hash}}
-        </div>
-        
-        <button onclick="window.history.back()">← Volver</button>
-        <button onclick="window.close()">Cerrar</button>
-    </div>
-</body>
-</html>`,
-	}
-
-	// Templates de Bancard
-	bancardTemplates := map[string]string{
-		"bancard_checkout.html": `<!DOCTYPE html>
-<html>
-<head>
-    <title>Bancard VPOS - Checkout</title>
-    <meta charset="utf-8">
-    <style>
-        body { font-family: Arial, sans-serif; margin: 0; background: linear-gradient(135deg, #1e3c72 0%, #2a5298 100%); min-height: 100vh; }
-        .container { max-width: 500px; margin: 0 auto; padding: 40px 20px; }
-        .checkout-card { background: white; border-radius: 12px; padding: 30px; box-shadow: 0 10px 30px rgba(0,0,0,0.2); }
-        .logo { text-align: center; margin-bottom: 30px; }
-        .logo h1 { color: #1e3c72; margin: 0; font-size: 28px; }
-        .order-info { background: #f8f9fa; padding: 20px; border-radius: 8px; margin-bottom: 30px; }
-        .actions { text-align: center; margin-top: 30px; }
-        .button { display: inline-block; padding: 15px 30px; margin: 10px; border: none; border-radius: 8px; font-size: 16px; cursor: pointer; text-decoration: none; font-weight: bold; }
-        .primary { background: #1e3c72; color: white; }
-        .error { background: #dc3545; color: white; }
-        .secondary { background: #6c757d; color: white; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="checkout-card">
-            <div class="logo">
-                <h1>Bancard VPOS</h1>
-                <p>Procesamiento Seguro de Pagos</p>
-            </div>
-            
-            <div class="order-info">
-                <h3>Información de la Transacción</h3>
-                <p><strong>Process ID:</strong> {{.data.ProcessID}}</p>
-                <p><strong>Monto:</strong> ₲ {{.data.Amount}}</p>
-                <p><strong>Moneda:</strong> {{.data.Currency}}</p>
-            </div>
-            
-            <div class="actions">
-                <button class="button primary" onclick="processPayment('success')">
-                    Procesar Pago (₲ {{.data.Amount}})
-                </button>
-                <button class="button error" onclick="processPayment('error')">
-                    Simular Error
-                </button>
-                <button class="button secondary" onclick="processPayment('cancel')">
-                    Cancelar
-                </button>
-            </div>
-        </div>
-    </div>
-
-    <script>
-        function processPayment(result) {
-            const processId = '{{.data.ProcessID}}';
-            
-            if (result === 'success') {
-                document.querySelector('.primary').innerHTML = 'Procesando...';
-                document.querySelector('.primary').disabled = true;
-                
-                setTimeout(() => {
-                    const returnUrl = '{{.data.ReturnURL}}' || '/bancard/return';
-                    const transactionId = 'TXN' + Math.random().toString(36).substr(2, 9).toUpperCase();
-                    window.location.href = returnUrl + '?status=success&transaction_id=' + transactionId + '&process_id=' + processId;
-                }, 2000);
-            } else if (result === 'error') {
-                alert('Simulando error: Tarjeta rechazada');
-                const returnUrl = '{{.data.ReturnURL}}' || '/bancard/return';
-                window.location.href = returnUrl + '?status=error&error_code=05&process_id=' + processId;
-            } else {
-                const cancelUrl = '{{.data.CancelURL}}' || '/bancard/cancel';
-                window.location.href = cancelUrl + '?status=cancelled&process_id=' + processId;
-            }
-        }
-    </script>
-</body>
-</html>`,
-
-		"bancard_result.html": `<!DOCTYPE html>
-<html>
-<head>
-    <title>Bancard VPOS - Resultado</title>
-    <meta charset="utf-8">
-    <style>
-        body { font-family: Arial, sans-serif; margin: 0; background: #f8f9fa; padding: 40px 20px; text-align: center; }
-        .container { max-width: 500px; margin: 0 auto; background: white; padding: 40px; border-radius: 12px; box-shadow: 0 4px 20px rgba(0,0,0,0.1); }
-        .status { font-size: 64px; margin: 20px 0; }
-        .message { font-size: 20px; margin: 20px 0; font-weight: bold; }
-        .details { background: #f8f9fa; padding: 20px; border-radius: 8px; margin: 20px 0; text-align: left; }
-        .button { display: inline-block; padding: 15px 30px; margin: 10px; border: none; border-radius: 8px; font-size: 16px; cursor: pointer; text-decoration: none; font-weight: bold; }
-        .primary { background: #1e3c72; color: white; }
-        .secondary { background: #6c757d; color: white; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        {{if eq .result "success"}}
-        <div class="status">Exito</div>
-        <div class="message" style="color: #28a745;">¡Pago Exitoso!</div>
-        {{else if eq .result "error"}}
-        <div class="status">Error</div>
-        <div class="message" style="color: #dc3545;">Error en el Pago</div>
-        {{else}}
-        <div class="status">Pendiente</div>
-        <div class="message" style="color: #ffc107;">Pago Cancelado</div>
-        {{end}}
-        
-        {{if .transaction_id}}
-        <div class="details">
-            <h4>Detalles de la Transacción</h4>
-            <p><strong>ID de Transacción:</strong> {{.transaction_id}}</p>
-            <p><strong>Estado:</strong> {{.status}}</p>
-            {{if .process_id}}<p><strong>Process ID:</strong> {{.process_id}}</p>{{end}}
-        </div>
-        {{end}}
-        
-        <div style="margin-top: 30px;">
-            <button class="button primary" onclick="window.history.back()">← Volver</button>
-            <button class="button secondary" onclick="window.close()">Cerrar</button>
-        </div>
-    </div>
-</body>
-</html>`,
-	}
-
-	// Registrar templates de Pagopar
-	for name, content := range pagoparTemplates {
-		templ = template.Must(templ.New(name).Parse(content))
-	}
-
-	// Registrar templates de Bancard
-	for name, content := range bancardTemplates {
-		templ = template.Must(templ.New(name).Parse(content))
-	}
+	return templ
 }
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"payment-emulator/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupWebhookRoutes configura la API del log de entregas de webhooks
+func setupWebhookRoutes(r *gin.Engine) {
+	api := r.Group("/emulator/api/webhooks")
+	{
+		api.GET("", handleListWebhooks)
+		api.GET("/:id", handleGetWebhook)
+		api.POST("/:id/redeliver", handleRedeliverWebhook)
+	}
+}
+
+// handleListWebhooks devuelve el log de entregas, opcionalmente filtrado por gateway
+func handleListWebhooks(c *gin.Context) {
+	gateway := c.Query("gateway")
+
+	deliveries := make([]*webhook.Delivery, 0)
+	for _, delivery := range webhook.GetGlobalDispatcher().List() {
+		if gateway == "" || delivery.Gateway == gateway {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"total":      len(deliveries),
+	})
+}
+
+// handleGetWebhook devuelve una entrega con todos sus intentos
+func handleGetWebhook(c *gin.Context) {
+	delivery, err := webhook.GetGlobalDispatcher().Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// handleRedeliverWebhook reenvía manualmente una entrega
+func handleRedeliverWebhook(c *gin.Context) {
+	delivery, err := webhook.GetGlobalDispatcher().Redeliver(c.Param("id"))
+	if errors.Is(err, webhook.ErrDeliveryNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
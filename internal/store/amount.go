@@ -0,0 +1,26 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseAmount convierte un monto en texto ("100000", "100000.00") a float64.
+// Rechaza montos negativos y los valores especiales "NaN" e "Inf" que acepta strconv.
+func ParseAmount(value string) (float64, error) {
+	amount, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount '%s'", value)
+	}
+	if amount < 0 || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, fmt.Errorf("invalid amount '%s'", value)
+	}
+	return amount, nil
+}
+
+// FormatAmount formatea un monto con dos decimales, como lo esperan las pasarelas
+func FormatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
@@ -0,0 +1,36 @@
+package store
+
+import "testing"
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+		ok    bool
+	}{
+		{"100000", 100000, true},
+		{"100000.00", 100000, true},
+		{" 1500.5 ", 1500.5, true},
+		{"0", 0, true},
+		{"-1", 0, false},
+		{"abc", 0, false},
+		{"", 0, false},
+		{"NaN", 0, false},
+		{"nan", 0, false},
+		{"Inf", 0, false},
+		{"+Inf", 0, false},
+		{"-Inf", 0, false},
+		{"Infinity", 0, false},
+		{"1e400", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.value)
+		if tt.ok && (err != nil || got != tt.want) {
+			t.Errorf("ParseAmount(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("ParseAmount(%q) = %v, want error", tt.value, got)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package store
+
+// Backend define el almacenamiento persistente detrás del store
+type Backend interface {
+	// Load devuelve las transacciones y tarjetas persistidas
+	Load() ([]*Transaction, []*Card, error)
+
+	// Save persiste el estado actual de una transacción
+	Save(tx *Transaction) error
+
+	// SaveCard persiste el estado actual de una tarjeta
+	SaveCard(card *Card) error
+
+	// DeleteCard elimina una tarjeta persistida
+	DeleteCard(card *Card) error
+
+	// Reset elimina todas las transacciones y tarjetas persistidas
+	Reset() error
+
+	// Close libera los recursos del backend
+	Close() error
+}
+
+// MemoryBackend es el backend por defecto: no persiste nada
+type MemoryBackend struct{}
+
+// NewMemoryBackend crea un backend en memoria
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{}
+}
+
+// Load no devuelve transacciones ni tarjetas
+func (b *MemoryBackend) Load() ([]*Transaction, []*Card, error) { return nil, nil, nil }
+
+// Save no realiza ninguna acción
+func (b *MemoryBackend) Save(tx *Transaction) error { return nil }
+
+// SaveCard no realiza ninguna acción
+func (b *MemoryBackend) SaveCard(card *Card) error { return nil }
+
+// DeleteCard no realiza ninguna acción
+func (b *MemoryBackend) DeleteCard(card *Card) error { return nil }
+
+// Reset no realiza ninguna acción
+func (b *MemoryBackend) Reset() error { return nil }
+
+// Close no realiza ninguna acción
+func (b *MemoryBackend) Close() error { return nil }
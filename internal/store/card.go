@@ -0,0 +1,194 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"payment-emulator/internal/clock"
+	"sort"
+	"time"
+)
+
+// ErrCardNotFound se devuelve cuando la tarjeta no existe
+var ErrCardNotFound = errors.New("card not found")
+
+// CardStatus representa el estado de una tarjeta catastrada
+type CardStatus string
+
+// Estados de tarjeta
+const (
+	CardPending    CardStatus = "pending"    // Registro iniciado, el usuario todavía no cargó la tarjeta
+	CardRegistered CardStatus = "registered" // Tarjeta catastrada, disponible para cobros
+)
+
+// Card representa una tarjeta catastrada (tokenizada) de un usuario del comercio
+type Card struct {
+	Gateway        string            `json:"gateway"`
+	ID             string            `json:"id"`
+	UserID         string            `json:"user_id"`
+	CardID         string            `json:"card_id"`
+	AliasToken     string            `json:"alias_token,omitempty"`
+	MaskedNumber   string            `json:"masked_number,omitempty"`
+	Brand          string            `json:"brand,omitempty"`
+	Type           string            `json:"type,omitempty"`
+	ExpirationDate string            `json:"expiration_date,omitempty"`
+	Status         CardStatus        `json:"status"`
+	ReturnURL      string            `json:"return_url,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// Meta devuelve un valor de metadata o cadena vacía si no existe
+func (c *Card) Meta(key string) string {
+	if c.Metadata == nil {
+		return ""
+	}
+	return c.Metadata[key]
+}
+
+// SetMeta asigna un valor de metadata
+func (c *Card) SetMeta(key, value string) {
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]string)
+	}
+	c.Metadata[key] = value
+}
+
+// clone devuelve una copia profunda de la tarjeta
+func (c *Card) clone() *Card {
+	copied := *c
+	if c.Metadata != nil {
+		copied.Metadata = make(map[string]string, len(c.Metadata))
+		for k, v := range c.Metadata {
+			copied.Metadata[k] = v
+		}
+	}
+	return &copied
+}
+
+// CreateCard registra una nueva tarjeta
+func (s *Store) CreateCard(card *Card) (*Card, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	k := key(card.Gateway, card.ID)
+	if _, exists := s.cards[k]; exists {
+		return nil, fmt.Errorf("%w: card %s", ErrDuplicate, k)
+	}
+
+	now := clock.Now()
+	stored := card.clone()
+	if stored.Status == "" {
+		stored.Status = CardPending
+	}
+	stored.CreatedAt = now
+	stored.UpdatedAt = now
+
+	if err := s.backend.SaveCard(stored); err != nil {
+		return nil, err
+	}
+	s.cards[k] = stored
+	return stored.clone(), nil
+}
+
+// GetCard obtiene una tarjeta por gateway e ID
+func (s *Store) GetCard(gateway, id string) (*Card, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	card, exists := s.cards[key(gateway, id)]
+	if !exists {
+		return nil, ErrCardNotFound
+	}
+	return card.clone(), nil
+}
+
+// FindCardByAlias obtiene una tarjeta catastrada por su alias_token
+func (s *Store) FindCardByAlias(gateway, aliasToken string) (*Card, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, card := range s.cards {
+		if card.Gateway == gateway && card.AliasToken == aliasToken && card.Status == CardRegistered {
+			return card.clone(), nil
+		}
+	}
+	return nil, ErrCardNotFound
+}
+
+// UpdateCard aplica una modificación atómica sobre una tarjeta existente
+func (s *Store) UpdateCard(gateway, id string, fn func(card *Card) error) (*Card, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	k := key(gateway, id)
+	current, exists := s.cards[k]
+	if !exists {
+		return nil, ErrCardNotFound
+	}
+
+	updated := current.clone()
+	if err := fn(updated); err != nil {
+		return nil, err
+	}
+	updated.UpdatedAt = clock.Now()
+
+	if err := s.backend.SaveCard(updated); err != nil {
+		return nil, err
+	}
+	s.cards[k] = updated
+	return updated.clone(), nil
+}
+
+// DeleteCard elimina una tarjeta
+func (s *Store) DeleteCard(gateway, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	k := key(gateway, id)
+	card, exists := s.cards[k]
+	if !exists {
+		return ErrCardNotFound
+	}
+
+	if err := s.backend.DeleteCard(card); err != nil {
+		return err
+	}
+	delete(s.cards, k)
+	return nil
+}
+
+// ListCards devuelve las tarjetas de un usuario (o de todos si userID es vacío), ordenadas por fecha
+func (s *Store) ListCards(gateway, userID string) []*Card {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	list := make([]*Card, 0)
+	for _, card := range s.cards {
+		if (gateway == "" || card.Gateway == gateway) && (userID == "" || card.UserID == userID) {
+			list = append(list, card.clone())
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list
+}
+
+// ImportCards agrega (o reemplaza) tarjetas conservando sus datos
+func (s *Store) ImportCards(cards []*Card) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, card := range cards {
+		if card.Gateway == "" || card.ID == "" {
+			return fmt.Errorf("invalid card: gateway and id are required")
+		}
+		if err := s.backend.SaveCard(card); err != nil {
+			return err
+		}
+		s.cards[key(card.Gateway, card.ID)] = card.clone()
+	}
+	return nil
+}
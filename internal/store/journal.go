@@ -0,0 +1,210 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// JournalFileName es el nombre del archivo de journal dentro del directorio de datos
+const JournalFileName = "transactions.jsonl"
+
+// journalEntry representa una línea del journal
+type journalEntry struct {
+	Transaction *Transaction `json:"transaction,omitempty"`
+	Card        *Card        `json:"card,omitempty"`
+	Deleted     bool         `json:"deleted,omitempty"` // La tarjeta de la entrada fue eliminada
+}
+
+// JournalBackend persiste las transacciones y tarjetas como un journal JSON-lines append-only.
+// Cada línea contiene el estado completo de una transacción o tarjeta; al cargar gana la última.
+type JournalBackend struct {
+	path  string
+	file  *os.File
+	mutex sync.Mutex
+}
+
+// OpenJournal abre (o crea) el journal en el directorio indicado y lo compacta
+func OpenJournal(dir string) (*JournalBackend, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data dir '%s': %w", dir, err)
+	}
+
+	b := &JournalBackend{path: filepath.Join(dir, JournalFileName)}
+
+	transactions, cards, err := b.Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := b.rewrite(transactions, cards); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Path devuelve la ruta del archivo de journal
+func (b *JournalBackend) Path() string {
+	return b.path
+}
+
+// Load lee el journal y devuelve el último estado de cada transacción y tarjeta
+func (b *JournalBackend) Load() ([]*Transaction, []*Card, error) {
+	file, err := os.Open(b.path)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open journal '%s': %w", b.path, err)
+	}
+	defer file.Close()
+
+	latest := make(map[string]*Transaction)
+	latestCards := make(map[string]*Card)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, nil, fmt.Errorf("invalid journal entry at %s:%d: %w", b.path, line, err)
+		}
+
+		if entry.Transaction != nil {
+			latest[key(entry.Transaction.Gateway, entry.Transaction.ID)] = entry.Transaction
+		}
+		if entry.Card != nil {
+			k := key(entry.Card.Gateway, entry.Card.ID)
+			if entry.Deleted {
+				delete(latestCards, k)
+			} else {
+				latestCards[k] = entry.Card
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read journal '%s': %w", b.path, err)
+	}
+
+	transactions := make([]*Transaction, 0, len(latest))
+	for _, tx := range latest {
+		transactions = append(transactions, tx)
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].CreatedAt.Before(transactions[j].CreatedAt)
+	})
+
+	cards := make([]*Card, 0, len(latestCards))
+	for _, card := range latestCards {
+		cards = append(cards, card)
+	}
+	sort.Slice(cards, func(i, j int) bool {
+		return cards[i].CreatedAt.Before(cards[j].CreatedAt)
+	})
+	return transactions, cards, nil
+}
+
+// Save agrega el estado de la transacción al final del journal
+func (b *JournalBackend) Save(tx *Transaction) error {
+	return b.append(journalEntry{Transaction: tx})
+}
+
+// SaveCard agrega el estado de la tarjeta al final del journal
+func (b *JournalBackend) SaveCard(card *Card) error {
+	return b.append(journalEntry{Card: card})
+}
+
+// DeleteCard registra la eliminación de la tarjeta en el journal
+func (b *JournalBackend) DeleteCard(card *Card) error {
+	return b.append(journalEntry{Card: card, Deleted: true})
+}
+
+// Reset vacía el journal
+func (b *JournalBackend) Reset() error {
+	return b.rewrite(nil, nil)
+}
+
+// Close cierra el archivo del journal
+func (b *JournalBackend) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.file == nil {
+		return nil
+	}
+	err := b.file.Close()
+	b.file = nil
+	return err
+}
+
+func (b *JournalBackend) append(entry journalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.file == nil {
+		file, err := os.OpenFile(b.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open journal '%s': %w", b.path, err)
+		}
+		b.file = file
+	}
+
+	_, err = b.file.Write(append(data, '\n'))
+	return err
+}
+
+// rewrite reemplaza el journal por una línea por transacción y por tarjeta
+func (b *JournalBackend) rewrite(transactions []*Transaction, cards []*Card) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.file != nil {
+		b.file.Close()
+		b.file = nil
+	}
+
+	tmpPath := b.path + ".tmp"
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to write journal '%s': %w", tmpPath, err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, tx := range transactions {
+		if err := encoder.Encode(journalEntry{Transaction: tx}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	for _, card := range cards {
+		if err := encoder.Encode(journalEntry{Card: card}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, b.path)
+}
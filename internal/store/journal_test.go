@@ -0,0 +1,163 @@
+package store
+
+import (
+	"bufio"
+	"os"
+	"testing"
+	"time"
+)
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines++
+	}
+	return lines
+}
+
+func TestJournalRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	journal, err := OpenJournal(dir)
+	if err != nil {
+		t.Fatalf("OpenJournal: %v", err)
+	}
+
+	created := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	first := &Transaction{Gateway: "bancard", ID: "1", Amount: 100000, Currency: "PYG", Status: StatusCreated, CreatedAt: created}
+	second := &Transaction{Gateway: "pagopar", ID: "abc", Amount: 50000.5, Currency: "PYG", Status: StatusPending, CreatedAt: created.Add(time.Minute)}
+	second.SetMeta("forma_pago", "9")
+	kept := &Card{Gateway: "bancard", ID: "c1", UserID: "u1", CardID: "1", CreatedAt: created}
+	deleted := &Card{Gateway: "bancard", ID: "c2", UserID: "u1", CardID: "2", CreatedAt: created}
+
+	paid := *first
+	paid.Status = StatusPaid
+
+	for _, step := range []func() error{
+		func() error { return journal.Save(first) },
+		func() error { return journal.Save(second) },
+		func() error { return journal.Save(&paid) },
+		func() error { return journal.SaveCard(kept) },
+		func() error { return journal.SaveCard(deleted) },
+		func() error { return journal.DeleteCard(deleted) },
+	} {
+		if err := step(); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	if err := journal.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	transactions, cards, err := journal.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if len(transactions) != 2 {
+		t.Fatalf("Load returned %d transactions, want 2", len(transactions))
+	}
+	if got := transactions[0]; got.ID != "1" || got.Status != StatusPaid {
+		t.Errorf("transactions[0] = %s (%s), want 1 (paid)", got.ID, got.Status)
+	}
+	if got := transactions[1]; got.ID != "abc" || got.Amount != 50000.5 || got.Meta("forma_pago") != "9" {
+		t.Errorf("transactions[1] = %+v, want abc with amount 50000.5 and forma_pago 9", got)
+	}
+
+	if len(cards) != 1 || cards[0].ID != "c1" {
+		t.Fatalf("Load returned cards %+v, want only c1", cards)
+	}
+}
+
+func TestJournalCompactsOnOpen(t *testing.T) {
+	dir := t.TempDir()
+	journal, err := OpenJournal(dir)
+	if err != nil {
+		t.Fatalf("OpenJournal: %v", err)
+	}
+
+	tx := &Transaction{Gateway: "bancard", ID: "1", Status: StatusCreated}
+	card := &Card{Gateway: "bancard", ID: "c1"}
+	for _, status := range []Status{StatusCreated, StatusPending, StatusPaid} {
+		tx.Status = status
+		if err := journal.Save(tx); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	if err := journal.SaveCard(card); err != nil {
+		t.Fatalf("SaveCard: %v", err)
+	}
+	if err := journal.SaveCard(&Card{Gateway: "bancard", ID: "c2"}); err != nil {
+		t.Fatalf("SaveCard: %v", err)
+	}
+	if err := journal.DeleteCard(&Card{Gateway: "bancard", ID: "c2"}); err != nil {
+		t.Fatalf("DeleteCard: %v", err)
+	}
+	journal.Close()
+
+	if got := countLines(t, journal.Path()); got != 6 {
+		t.Fatalf("journal has %d lines before compaction, want 6", got)
+	}
+
+	reopened, err := OpenJournal(dir)
+	if err != nil {
+		t.Fatalf("OpenJournal: %v", err)
+	}
+	defer reopened.Close()
+
+	if got := countLines(t, reopened.Path()); got != 2 {
+		t.Errorf("journal has %d lines after compaction, want 2", got)
+	}
+	if _, err := os.Stat(reopened.Path() + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary journal was not removed: %v", err)
+	}
+
+	transactions, cards, err := reopened.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(transactions) != 1 || transactions[0].Status != StatusPaid {
+		t.Errorf("Load after compaction = %+v, want one paid transaction", transactions)
+	}
+	if len(cards) != 1 || cards[0].ID != "c1" {
+		t.Errorf("Load after compaction returned cards %+v, want only c1", cards)
+	}
+}
+
+func TestJournalReset(t *testing.T) {
+	journal, err := OpenJournal(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenJournal: %v", err)
+	}
+	defer journal.Close()
+
+	if err := journal.Save(&Transaction{Gateway: "bancard", ID: "1"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := journal.Reset(); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+
+	transactions, cards, err := journal.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(transactions) != 0 || len(cards) != 0 {
+		t.Errorf("Load after Reset returned %d transactions and %d cards, want none", len(transactions), len(cards))
+	}
+
+	// El journal sigue aceptando entradas después de vaciarse
+	if err := journal.Save(&Transaction{Gateway: "bancard", ID: "2"}); err != nil {
+		t.Fatalf("Save after Reset: %v", err)
+	}
+	if transactions, _, _ := journal.Load(); len(transactions) != 1 || transactions[0].ID != "2" {
+		t.Errorf("Load after Save = %+v, want only 2", transactions)
+	}
+}
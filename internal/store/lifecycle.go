@@ -0,0 +1,57 @@
+package store
+
+import "fmt"
+
+// Lifecycle define las transiciones de estado permitidas para un gateway
+type Lifecycle struct {
+	// Transitions indica, para cada estado, los estados a los que se puede pasar
+	Transitions map[Status][]Status
+
+	// Reject construye el error específico del gateway para una transición inválida.
+	// Si es nil se devuelve un *TransitionError.
+	Reject func(err *TransitionError) error
+}
+
+// TransitionError se devuelve cuando una transición no está permitida
+type TransitionError struct {
+	Gateway string
+	ID      string
+	From    Status
+	To      Status
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("invalid transition for %s:%s: %s -> %s", e.Gateway, e.ID, e.From, e.To)
+}
+
+// DefaultLifecycle es el ciclo de vida usado por gateways que no registran uno propio:
+// created → pending → paid / failed / cancelled / expired → refunded / reversed
+var DefaultLifecycle = Lifecycle{
+	Transitions: map[Status][]Status{
+		StatusCreated: {StatusPending, StatusPaid, StatusFailed, StatusCancelled, StatusExpired},
+		StatusPending: {StatusPaid, StatusFailed, StatusCancelled, StatusExpired},
+		StatusPaid:    {StatusRefunded, StatusReversed},
+	},
+}
+
+// Allows indica si la transición from → to está permitida
+func (l Lifecycle) Allows(from, to Status) bool {
+	for _, allowed := range l.Transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinal indica si un estado no admite más transiciones
+func (l Lifecycle) IsFinal(status Status) bool {
+	return len(l.Transitions[status]) == 0
+}
+
+func (l Lifecycle) reject(err *TransitionError) error {
+	if l.Reject == nil {
+		return err
+	}
+	return l.Reject(err)
+}
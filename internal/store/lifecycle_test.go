@@ -0,0 +1,52 @@
+package store
+
+import "testing"
+
+func TestDefaultLifecycleAllows(t *testing.T) {
+	tests := []struct {
+		from, to Status
+		want     bool
+	}{
+		{StatusCreated, StatusPending, true},
+		{StatusCreated, StatusPaid, true},
+		{StatusCreated, StatusExpired, true},
+		{StatusCreated, StatusRefunded, false},
+		{StatusPending, StatusPaid, true},
+		{StatusPending, StatusCancelled, true},
+		{StatusPending, StatusPending, false},
+		{StatusPending, StatusCreated, false},
+		{StatusPaid, StatusRefunded, true},
+		{StatusPaid, StatusReversed, true},
+		{StatusPaid, StatusCancelled, false},
+		{StatusFailed, StatusPaid, false},
+		{StatusRefunded, StatusPaid, false},
+	}
+
+	for _, tt := range tests {
+		if got := DefaultLifecycle.Allows(tt.from, tt.to); got != tt.want {
+			t.Errorf("Allows(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultLifecycleIsFinal(t *testing.T) {
+	tests := []struct {
+		status Status
+		want   bool
+	}{
+		{StatusCreated, false},
+		{StatusPending, false},
+		{StatusPaid, false},
+		{StatusFailed, true},
+		{StatusCancelled, true},
+		{StatusExpired, true},
+		{StatusRefunded, true},
+		{StatusReversed, true},
+	}
+
+	for _, tt := range tests {
+		if got := DefaultLifecycle.IsFinal(tt.status); got != tt.want {
+			t.Errorf("IsFinal(%s) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SnapshotVersion es la versión actual del formato de exportación.
+// La versión 2 agrega las tarjetas catastradas.
+const SnapshotVersion = 2
+
+// Snapshot representa el estado exportable del store (fixtures compartibles)
+type Snapshot struct {
+	Version      int            `json:"version"`
+	Transactions []*Transaction `json:"transactions"`
+	Cards        []*Card        `json:"cards,omitempty"`
+}
+
+// Snapshot devuelve el estado completo del store
+func (s *Store) Snapshot() *Snapshot {
+	return &Snapshot{
+		Version:      SnapshotVersion,
+		Transactions: s.List(""),
+		Cards:        s.ListCards("", ""),
+	}
+}
+
+// Restore importa el contenido de un snapshot al store
+func (s *Store) Restore(snapshot *Snapshot) error {
+	if snapshot.Version > SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+	if err := s.Import(snapshot.Transactions); err != nil {
+		return err
+	}
+	return s.ImportCards(snapshot.Cards)
+}
+
+// WriteSnapshot serializa un snapshot como JSON
+func WriteSnapshot(w io.Writer, snapshot *Snapshot) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshot)
+}
+
+// ReadSnapshot lee un snapshot serializado como JSON
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
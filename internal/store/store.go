@@ -0,0 +1,263 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"payment-emulator/internal/clock"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrNotFound se devuelve cuando la transacción no existe
+	ErrNotFound = errors.New("transaction not found")
+
+	// ErrDuplicate se devuelve cuando ya existe una transacción con el mismo ID
+	ErrDuplicate = errors.New("transaction already exists")
+)
+
+// Store mantiene las transacciones de todos los gateways
+type Store struct {
+	transactions map[string]*Transaction
+	cards        map[string]*Card
+	lifecycles   map[string]Lifecycle
+	backend      Backend
+	mutex        sync.RWMutex
+}
+
+// NewStore crea un nuevo store en memoria
+func NewStore() *Store {
+	return &Store{
+		transactions: make(map[string]*Transaction),
+		cards:        make(map[string]*Card),
+		lifecycles:   make(map[string]Lifecycle),
+		backend:      NewMemoryBackend(),
+	}
+}
+
+// SetBackend reemplaza el backend de persistencia y carga sus transacciones y tarjetas
+func (s *Store) SetBackend(backend Backend) error {
+	transactions, cards, err := backend.Load()
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.backend = backend
+	s.transactions = make(map[string]*Transaction, len(transactions))
+	for _, tx := range transactions {
+		s.transactions[key(tx.Gateway, tx.ID)] = tx.clone()
+	}
+	s.cards = make(map[string]*Card, len(cards))
+	for _, card := range cards {
+		s.cards[key(card.Gateway, card.ID)] = card.clone()
+	}
+	return nil
+}
+
+// Close cierra el backend de persistencia
+func (s *Store) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.backend.Close()
+}
+
+// RegisterLifecycle registra el ciclo de vida de las transacciones de un gateway
+func (s *Store) RegisterLifecycle(gateway string, lifecycle Lifecycle) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lifecycles[gateway] = lifecycle
+}
+
+// GetLifecycle devuelve el ciclo de vida registrado para un gateway
+func (s *Store) GetLifecycle(gateway string) Lifecycle {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if lifecycle, exists := s.lifecycles[gateway]; exists {
+		return lifecycle
+	}
+	return DefaultLifecycle
+}
+
+func key(gateway, id string) string {
+	return gateway + ":" + id
+}
+
+// Create registra una nueva transacción
+func (s *Store) Create(tx *Transaction) (*Transaction, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	k := key(tx.Gateway, tx.ID)
+	if _, exists := s.transactions[k]; exists {
+		return nil, fmt.Errorf("%w: %s", ErrDuplicate, k)
+	}
+
+	now := clock.Now()
+	stored := tx.clone()
+	if stored.Status == "" {
+		stored.Status = StatusCreated
+	}
+	stored.CreatedAt = now
+	stored.UpdatedAt = now
+	stored.History = append(stored.History, StatusChange{Status: stored.Status, At: now})
+
+	if err := s.backend.Save(stored); err != nil {
+		return nil, err
+	}
+	s.transactions[k] = stored
+	return stored.clone(), nil
+}
+
+// Get obtiene una transacción por gateway e ID
+func (s *Store) Get(gateway, id string) (*Transaction, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	tx, exists := s.transactions[key(gateway, id)]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return tx.clone(), nil
+}
+
+// Find obtiene la primera transacción del gateway que cumple match, por ejemplo la de una
+// referencia dentro de un comercio (las referencias solo son únicas por comercio)
+func (s *Store) Find(gateway string, match func(tx *Transaction) bool) (*Transaction, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, tx := range s.transactions {
+		if tx.Gateway == gateway && match(tx) {
+			return tx.clone(), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Update aplica una modificación atómica sobre una transacción existente
+func (s *Store) Update(gateway, id string, fn func(tx *Transaction) error) (*Transaction, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	k := key(gateway, id)
+	current, exists := s.transactions[k]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	updated := current.clone()
+	if err := fn(updated); err != nil {
+		return nil, err
+	}
+	updated.UpdatedAt = clock.Now()
+
+	if err := s.backend.Save(updated); err != nil {
+		return nil, err
+	}
+	s.transactions[k] = updated
+	return updated.clone(), nil
+}
+
+// Transition cambia el estado de una transacción validando el ciclo de vida del gateway.
+// La función fn (opcional) se aplica sobre la transacción antes de registrar el cambio.
+func (s *Store) Transition(gateway, id string, to Status, note string, fn func(tx *Transaction) error) (*Transaction, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	k := key(gateway, id)
+	current, exists := s.transactions[k]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	lifecycle, registered := s.lifecycles[gateway]
+	if !registered {
+		lifecycle = DefaultLifecycle
+	}
+	if !lifecycle.Allows(current.Status, to) {
+		return nil, lifecycle.reject(&TransitionError{
+			Gateway: gateway,
+			ID:      id,
+			From:    current.Status,
+			To:      to,
+		})
+	}
+
+	updated := current.clone()
+	if fn != nil {
+		if err := fn(updated); err != nil {
+			return nil, err
+		}
+	}
+
+	now := clock.Now()
+	updated.Status = to
+	updated.History = append(updated.History, StatusChange{Status: to, Note: note, At: now})
+	updated.UpdatedAt = now
+
+	if err := s.backend.Save(updated); err != nil {
+		return nil, err
+	}
+	s.transactions[k] = updated
+	return updated.clone(), nil
+}
+
+// List devuelve las transacciones de un gateway (o todas si gateway es vacío), ordenadas por fecha
+func (s *Store) List(gateway string) []*Transaction {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	list := make([]*Transaction, 0, len(s.transactions))
+	for _, tx := range s.transactions {
+		if gateway == "" || tx.Gateway == gateway {
+			list = append(list, tx.clone())
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list
+}
+
+// Import agrega (o reemplaza) transacciones conservando sus estados e historial
+func (s *Store) Import(transactions []*Transaction) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, tx := range transactions {
+		if tx.Gateway == "" || tx.ID == "" {
+			return fmt.Errorf("invalid transaction: gateway and id are required")
+		}
+		if err := s.backend.Save(tx); err != nil {
+			return err
+		}
+		s.transactions[key(tx.Gateway, tx.ID)] = tx.clone()
+	}
+	return nil
+}
+
+// Reset elimina todas las transacciones y tarjetas
+func (s *Store) Reset() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.backend.Reset(); err != nil {
+		return err
+	}
+	s.transactions = make(map[string]*Transaction)
+	s.cards = make(map[string]*Card)
+	return nil
+}
+
+// Global store instance
+var globalStore = NewStore()
+
+// GetGlobalStore devuelve la instancia global del store
+func GetGlobalStore() *Store {
+	return globalStore
+}
@@ -0,0 +1,102 @@
+package store
+
+import "time"
+
+// Status representa el estado de una transacción dentro del emulador
+type Status string
+
+// Estados de transacción
+const (
+	StatusCreated    Status = "created"
+	StatusPending    Status = "pending"
+	StatusAuthorized Status = "authorized" // Fondos retenidos (preautorización), pendiente de captura
+	StatusPaid       Status = "paid"
+	StatusFailed     Status = "failed"
+	StatusCancelled  Status = "cancelled"
+	StatusExpired    Status = "expired"
+	StatusRefunded   Status = "refunded"
+	StatusReversed   Status = "reversed"
+)
+
+// Transaction representa una transacción almacenada por cualquier gateway
+type Transaction struct {
+	Gateway     string            `json:"gateway"`
+	ID          string            `json:"id"`
+	Reference   string            `json:"reference,omitempty"`
+	Amount      float64           `json:"amount"`
+	Currency    string            `json:"currency"`
+	Description string            `json:"description,omitempty"`
+	Buyer       Buyer             `json:"buyer"`
+	Items       []Item            `json:"items,omitempty"`
+	ReturnURL   string            `json:"return_url,omitempty"`
+	CancelURL   string            `json:"cancel_url,omitempty"`
+	Status      Status            `json:"status"`
+	History     []StatusChange    `json:"history"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// Buyer representa los datos del comprador de una transacción
+type Buyer struct {
+	Name         string `json:"name,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	Document     string `json:"document,omitempty"`
+	DocumentType string `json:"document_type,omitempty"`
+}
+
+// Item representa un item de compra de una transacción
+type Item struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Quantity    int     `json:"quantity"`
+	Amount      float64 `json:"amount"`
+}
+
+// StatusChange representa un cambio de estado en el historial
+type StatusChange struct {
+	Status Status    `json:"status"`
+	Note   string    `json:"note,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// Meta devuelve un valor de metadata o cadena vacía si no existe
+func (t *Transaction) Meta(key string) string {
+	if t.Metadata == nil {
+		return ""
+	}
+	return t.Metadata[key]
+}
+
+// SetMeta asigna un valor de metadata
+func (t *Transaction) SetMeta(key, value string) {
+	if t.Metadata == nil {
+		t.Metadata = make(map[string]string)
+	}
+	t.Metadata[key] = value
+}
+
+// StatusAt devuelve la fecha del último cambio al estado indicado
+func (t *Transaction) StatusAt(status Status) (time.Time, bool) {
+	for i := len(t.History) - 1; i >= 0; i-- {
+		if t.History[i].Status == status {
+			return t.History[i].At, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// clone devuelve una copia profunda de la transacción
+func (t *Transaction) clone() *Transaction {
+	c := *t
+	c.Items = append([]Item(nil), t.Items...)
+	c.History = append([]StatusChange(nil), t.History...)
+	if t.Metadata != nil {
+		c.Metadata = make(map[string]string, len(t.Metadata))
+		for k, v := range t.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	return &c
+}
@@ -0,0 +1,74 @@
+package webhook
+
+import "time"
+
+// DeliveryStatus representa el estado de una entrega de webhook
+type DeliveryStatus string
+
+// Estados de entrega
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryRetrying  DeliveryStatus = "retrying"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Request representa una notificación saliente hacia el comercio
+type Request struct {
+	Gateway       string            `json:"gateway"`
+	Event         string            `json:"event"`
+	TransactionID string            `json:"transaction_id,omitempty"`
+	URL           string            `json:"url"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Body          []byte            `json:"-"`
+}
+
+// Attempt representa un intento de entrega
+type Attempt struct {
+	Number       int       `json:"number"`
+	StartedAt    time.Time `json:"started_at"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	LatencyMs    int64     `json:"latency_ms"`
+	Manual       bool      `json:"manual,omitempty"`
+}
+
+// Delivery representa una entrega de webhook con su log de intentos
+type Delivery struct {
+	ID            string            `json:"id"`
+	Gateway       string            `json:"gateway"`
+	Event         string            `json:"event"`
+	TransactionID string            `json:"transaction_id,omitempty"`
+	URL           string            `json:"url"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	RequestBody   string            `json:"request_body"`
+	Status        DeliveryStatus    `json:"status"`
+	Attempts      []Attempt         `json:"attempts"`
+	NextAttemptAt *time.Time        `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+
+	// pendingAttempts es la cantidad de intentos que quedan en la ronda actual
+	pendingAttempts int
+	manual          bool
+}
+
+// LastAttempt devuelve el último intento realizado, si existe
+func (d *Delivery) LastAttempt() *Attempt {
+	if len(d.Attempts) == 0 {
+		return nil
+	}
+	return &d.Attempts[len(d.Attempts)-1]
+}
+
+// clone devuelve una copia de la entrega
+func (d *Delivery) clone() *Delivery {
+	c := *d
+	c.Attempts = append([]Attempt(nil), d.Attempts...)
+	if d.NextAttemptAt != nil {
+		next := *d.NextAttemptAt
+		c.NextAttemptAt = &next
+	}
+	return &c
+}
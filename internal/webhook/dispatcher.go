@@ -0,0 +1,268 @@
+package webhook
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrDeliveryNotFound se devuelve cuando la entrega no existe en el log
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+	// ErrDeliveryInProgress se devuelve al reenviar una entrega que todavía tiene intentos pendientes
+	ErrDeliveryInProgress = errors.New("webhook delivery still in progress")
+)
+
+// Config define la política de entrega de webhooks
+type Config struct {
+	MaxAttempts int           // Intentos por ronda (1 = sin reintentos)
+	Backoff     time.Duration // Espera antes del primer reintento; se duplica en cada intento
+	MaxBackoff  time.Duration // Espera máxima entre reintentos
+	Timeout     time.Duration // Timeout de cada request
+	LogSize     int           // Cantidad máxima de entregas en el log
+}
+
+// DefaultConfig es la configuración por defecto del dispatcher
+var DefaultConfig = Config{
+	MaxAttempts: 5,
+	Backoff:     time.Second,
+	MaxBackoff:  time.Minute,
+	Timeout:     10 * time.Second,
+	LogSize:     500,
+}
+
+// Dispatcher encola y entrega webhooks con reintentos y backoff exponencial
+type Dispatcher struct {
+	config     Config
+	client     *http.Client
+	deliveries map[string]*Delivery
+	order      []string
+	queue      chan string
+	sequence   int
+	mutex      sync.RWMutex
+}
+
+// NewDispatcher crea un dispatcher e inicia sus workers
+func NewDispatcher(config Config, workers int) *Dispatcher {
+	d := &Dispatcher{
+		deliveries: make(map[string]*Delivery),
+		queue:      make(chan string, 1024),
+	}
+	d.Configure(config)
+
+	for i := 0; i < workers; i++ {
+		go d.worker()
+	}
+	return d
+}
+
+// Configure actualiza la política de entrega
+func (d *Dispatcher) Configure(config Config) {
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+	if config.LogSize < 1 {
+		config.LogSize = DefaultConfig.LogSize
+	}
+	if config.MaxBackoff < config.Backoff {
+		config.MaxBackoff = config.Backoff
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.config = config
+	d.client = &http.Client{Timeout: config.Timeout}
+}
+
+// Enqueue registra una nueva entrega y la pone en cola
+func (d *Dispatcher) Enqueue(req Request) *Delivery {
+	d.mutex.Lock()
+
+	d.sequence++
+	now := time.Now()
+	delivery := &Delivery{
+		ID:              fmt.Sprintf("whd_%d_%d", now.Unix(), d.sequence),
+		Gateway:         req.Gateway,
+		Event:           req.Event,
+		TransactionID:   req.TransactionID,
+		URL:             req.URL,
+		Headers:         req.Headers,
+		RequestBody:     string(req.Body),
+		Status:          DeliveryPending,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		pendingAttempts: d.config.MaxAttempts,
+	}
+
+	d.deliveries[delivery.ID] = delivery
+	d.order = append(d.order, delivery.ID)
+	d.trim()
+	snapshot := delivery.clone()
+	d.mutex.Unlock()
+
+	d.queue <- snapshot.ID
+	return snapshot
+}
+
+// Redeliver vuelve a encolar una entrega existente con una nueva ronda de intentos
+func (d *Dispatcher) Redeliver(id string) (*Delivery, error) {
+	d.mutex.Lock()
+
+	delivery, exists := d.deliveries[id]
+	if !exists {
+		d.mutex.Unlock()
+		return nil, ErrDeliveryNotFound
+	}
+	if delivery.Status == DeliveryPending || delivery.Status == DeliveryRetrying {
+		d.mutex.Unlock()
+		return nil, ErrDeliveryInProgress
+	}
+
+	delivery.Status = DeliveryPending
+	delivery.NextAttemptAt = nil
+	delivery.UpdatedAt = time.Now()
+	delivery.pendingAttempts = d.config.MaxAttempts
+	delivery.manual = true
+	snapshot := delivery.clone()
+	d.mutex.Unlock()
+
+	d.queue <- snapshot.ID
+	return snapshot, nil
+}
+
+// Get obtiene una entrega del log
+func (d *Dispatcher) Get(id string) (*Delivery, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	delivery, exists := d.deliveries[id]
+	if !exists {
+		return nil, ErrDeliveryNotFound
+	}
+	return delivery.clone(), nil
+}
+
+// List devuelve el log de entregas, de la más reciente a la más antigua
+func (d *Dispatcher) List() []*Delivery {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	list := make([]*Delivery, 0, len(d.deliveries))
+	for _, delivery := range d.deliveries {
+		list = append(list, delivery.clone())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list
+}
+
+// trim descarta las entregas más antiguas que excedan el tamaño del log
+func (d *Dispatcher) trim() {
+	for len(d.order) > d.config.LogSize {
+		delete(d.deliveries, d.order[0])
+		d.order = d.order[1:]
+	}
+}
+
+func (d *Dispatcher) worker() {
+	for id := range d.queue {
+		d.attempt(id)
+	}
+}
+
+// attempt realiza un intento de entrega y programa el reintento si corresponde
+func (d *Dispatcher) attempt(id string) {
+	d.mutex.Lock()
+	delivery, exists := d.deliveries[id]
+	if !exists {
+		d.mutex.Unlock()
+		return
+	}
+	req := delivery.clone()
+	client := d.client
+	manual := delivery.manual
+	delivery.manual = false
+	d.mutex.Unlock()
+
+	attempt := Attempt{
+		Number:    len(req.Attempts) + 1,
+		StartedAt: time.Now(),
+		Manual:    manual,
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, req.URL, bytes.NewReader([]byte(req.RequestBody)))
+	if err == nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+		for k, v := range req.Headers {
+			httpReq.Header.Set(k, v)
+		}
+
+		var resp *http.Response
+		resp, err = client.Do(httpReq)
+		if err == nil {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+			attempt.StatusCode = resp.StatusCode
+			attempt.ResponseBody = string(body)
+		}
+	}
+	attempt.LatencyMs = time.Since(attempt.StartedAt).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	delivery, exists = d.deliveries[id]
+	if !exists {
+		return
+	}
+
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	delivery.UpdatedAt = time.Now()
+	delivery.pendingAttempts--
+
+	if err == nil && attempt.StatusCode >= 200 && attempt.StatusCode < 300 {
+		delivery.Status = DeliveryDelivered
+		delivery.NextAttemptAt = nil
+		fmt.Printf("Webhook %s (%s) entregado a %s: %d\n", delivery.ID, delivery.Event, delivery.URL, attempt.StatusCode)
+		return
+	}
+
+	if delivery.pendingAttempts <= 0 {
+		delivery.Status = DeliveryFailed
+		delivery.NextAttemptAt = nil
+		fmt.Printf("Webhook %s (%s) falló definitivamente tras %d intentos\n", delivery.ID, delivery.Event, len(delivery.Attempts))
+		return
+	}
+
+	// Backoff exponencial: backoff * 2^(intentos de la ronda - 1)
+	roundAttempt := d.config.MaxAttempts - delivery.pendingAttempts
+	wait := d.config.Backoff << (roundAttempt - 1)
+	if wait > d.config.MaxBackoff || wait <= 0 {
+		wait = d.config.MaxBackoff
+	}
+	next := time.Now().Add(wait)
+	delivery.Status = DeliveryRetrying
+	delivery.NextAttemptAt = &next
+
+	time.AfterFunc(wait, func() {
+		d.queue <- id
+	})
+}
+
+// Global dispatcher instance
+var globalDispatcher = NewDispatcher(DefaultConfig, 4)
+
+// GetGlobalDispatcher devuelve la instancia global del dispatcher
+func GetGlobalDispatcher() *Dispatcher {
+	return globalDispatcher
+}
@@ -0,0 +1,130 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testConfig usa esperas cortas para que los reintentos ocurran dentro del test
+var testConfig = Config{
+	MaxAttempts: 4,
+	Backoff:     40 * time.Millisecond,
+	MaxBackoff:  100 * time.Millisecond,
+	Timeout:     time.Second,
+	LogSize:     10,
+}
+
+// merchantServer responde con los códigos indicados, uno por petición; al agotarlos repite el último
+func merchantServer(t *testing.T, codes ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&requests, 1))
+		if n > len(codes) {
+			n = len(codes)
+		}
+		w.WriteHeader(codes[n-1])
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// waitForStatus espera a que la entrega llegue a un estado final
+func waitForStatus(t *testing.T, d *Dispatcher, id string, status DeliveryStatus) *Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		delivery, err := d.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%s): %v", id, err)
+		}
+		if delivery.Status == status {
+			return delivery
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	delivery, _ := d.Get(id)
+	t.Fatalf("delivery %s is %s after 5s, want %s", id, delivery.Status, status)
+	return nil
+}
+
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	server, requests := merchantServer(t, http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusOK)
+	d := NewDispatcher(testConfig, 1)
+
+	delivery := d.Enqueue(Request{Gateway: "pagopar", Event: "pagopar.pago", URL: server.URL, Body: []byte(`{}`)})
+	delivered := waitForStatus(t, d, delivery.ID, DeliveryDelivered)
+
+	if got := atomic.LoadInt32(requests); got != 3 {
+		t.Errorf("merchant received %d requests, want 3", got)
+	}
+	if len(delivered.Attempts) != 3 {
+		t.Fatalf("delivery has %d attempts, want 3", len(delivered.Attempts))
+	}
+
+	// La espera se duplica en cada reintento: 40ms y luego 80ms
+	wantGaps := []time.Duration{testConfig.Backoff, 2 * testConfig.Backoff}
+	for i, want := range wantGaps {
+		gap := delivered.Attempts[i+1].StartedAt.Sub(delivered.Attempts[i].StartedAt)
+		if gap < want {
+			t.Errorf("gap before attempt %d = %s, want at least %s", i+2, gap, want)
+		}
+	}
+	for i, code := range []int{500, 503, 200} {
+		if delivered.Attempts[i].StatusCode != code {
+			t.Errorf("attempt %d status = %d, want %d", i+1, delivered.Attempts[i].StatusCode, code)
+		}
+	}
+	if delivered.NextAttemptAt != nil {
+		t.Errorf("delivered webhook still has next_attempt_at %s", delivered.NextAttemptAt)
+	}
+}
+
+func TestDispatcherFailsAfterMaxAttemptsAndRedelivers(t *testing.T) {
+	server, requests := merchantServer(t, http.StatusInternalServerError)
+	config := testConfig
+	config.MaxAttempts = 2
+	d := NewDispatcher(config, 1)
+
+	delivery := d.Enqueue(Request{Gateway: "bancard", Event: "bancard.confirmation", URL: server.URL, Body: []byte(`{}`)})
+	failed := waitForStatus(t, d, delivery.ID, DeliveryFailed)
+	if len(failed.Attempts) != 2 {
+		t.Fatalf("failed delivery has %d attempts, want 2", len(failed.Attempts))
+	}
+
+	// El reenvío manual abre una nueva ronda de intentos
+	if _, err := d.Redeliver(delivery.ID); err != nil {
+		t.Fatalf("Redeliver: %v", err)
+	}
+	failed = waitForStatus(t, d, delivery.ID, DeliveryFailed)
+	if len(failed.Attempts) != 4 {
+		t.Fatalf("delivery has %d attempts after redelivery, want 4", len(failed.Attempts))
+	}
+	if !failed.Attempts[2].Manual || failed.Attempts[3].Manual {
+		t.Errorf("only the first attempt of the redelivery should be manual: %+v", failed.Attempts)
+	}
+	if got := atomic.LoadInt32(requests); got != 4 {
+		t.Errorf("merchant received %d requests, want 4", got)
+	}
+}
+
+func TestDispatcherRedeliverInProgress(t *testing.T) {
+	server, _ := merchantServer(t, http.StatusInternalServerError)
+	config := testConfig
+	config.Backoff = time.Second
+	config.MaxBackoff = time.Second
+	d := NewDispatcher(config, 1)
+
+	delivery := d.Enqueue(Request{Gateway: "pagopar", Event: "pagopar.pago", URL: server.URL, Body: []byte(`{}`)})
+	waitForStatus(t, d, delivery.ID, DeliveryRetrying)
+
+	if _, err := d.Redeliver(delivery.ID); err != ErrDeliveryInProgress {
+		t.Errorf("Redeliver during retries = %v, want ErrDeliveryInProgress", err)
+	}
+	if _, err := d.Redeliver("whd_missing"); err != ErrDeliveryNotFound {
+		t.Errorf("Redeliver(unknown) = %v, want ErrDeliveryNotFound", err)
+	}
+}
@@ -0,0 +1,395 @@
+package bancard
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"payment-emulator/internal/store"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleNewCard inicia el catastro de una tarjeta (cards/new) y devuelve el process_id del iframe
+func (p *BancardPlugin) handleNewCard(c *gin.Context) {
+	var request BancardNewCardRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+
+	operation := request.Operation
+	_, err := p.verifyToken(request.PublicKey, operation.Token, func(privateKey string) string {
+		return newCardToken(privateKey, operation.CardID, operation.UserID)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	for _, card := range p.merchantCards(request.PublicKey, operation.UserID) {
+		if card.CardID == operation.CardID {
+			respondError(c, newBancardError(http.StatusBadRequest, ErrKeyInvalidOperation, "El usuario ya tiene una tarjeta con card_id "+operation.CardID))
+			return
+		}
+	}
+
+	processID := generateProcessID()
+	card := &store.Card{
+		Gateway:   GatewayName,
+		ID:        processID,
+		UserID:    operation.UserID,
+		CardID:    operation.CardID,
+		ReturnURL: operation.ReturnURL,
+	}
+	card.SetMeta(MetaPublicKey, request.PublicKey)
+	card.SetMeta(MetaUserCellPhone, operation.UserCellPhone)
+	card.SetMeta(MetaUserMail, operation.UserMail)
+
+	if _, err := p.store.CreateCard(card); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, BancardOrderResponse{
+		Status:      StatusSuccess,
+		ProcessID:   processID,
+		RedirectURL: fmt.Sprintf("/bancard/cards/new/%s", processID),
+	})
+}
+
+// handleUserCards lista las tarjetas catastradas de un usuario
+func (p *BancardPlugin) handleUserCards(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	var request BancardUserCardsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+
+	_, err := p.verifyToken(request.PublicKey, request.Operation.Token, func(privateKey string) string {
+		return userCardsToken(privateKey, userID)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	cards := make([]BancardCard, 0)
+	for _, card := range p.merchantCards(request.PublicKey, userID) {
+		cards = append(cards, BancardCard{
+			AliasToken:       card.AliasToken,
+			CardMaskedNumber: card.MaskedNumber,
+			ExpirationDate:   card.ExpirationDate,
+			CardBrand:        card.Brand,
+			CardID:           card.CardID,
+			CardType:         card.Type,
+		})
+	}
+
+	c.JSON(http.StatusOK, BancardUserCardsResponse{
+		Status: StatusSuccess,
+		Cards:  cards,
+	})
+}
+
+// handleDeleteCard elimina una tarjeta catastrada de un usuario
+func (p *BancardPlugin) handleDeleteCard(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	var request BancardUserCardsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+
+	aliasToken := request.Operation.AliasToken
+	_, err := p.verifyToken(request.PublicKey, request.Operation.Token, func(privateKey string) string {
+		return deleteCardToken(privateKey, userID, aliasToken)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	card, err := p.findCard(request.PublicKey, aliasToken)
+	if err != nil || card.UserID != userID {
+		respondError(c, cardNotFoundError())
+		return
+	}
+
+	if err := p.store.DeleteCard(GatewayName, card.ID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": StatusSuccess})
+}
+
+// handleCharge cobra con una tarjeta catastrada. El pago queda confirmado en el momento
+// y la confirmación se devuelve en la respuesta, sin pasar por el iframe.
+func (p *BancardPlugin) handleCharge(c *gin.Context) {
+	var request BancardChargeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+
+	operation := request.Operation
+	amount, err := store.ParseAmount(operation.Amount)
+	if err != nil || amount <= 0 {
+		respondError(c, newBancardError(http.StatusBadRequest, ErrKeyInvalidAmount, "operation.amount inválido: "+operation.Amount))
+		return
+	}
+
+	currency := firstNonEmpty(operation.Currency, CurrencyPYG)
+	merchant, err := p.verifyToken(request.PublicKey, operation.Token, func(privateKey string) string {
+		return chargeToken(privateKey, operation.ShopProcessID, operation.Amount, currency, operation.AliasToken)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if merchant != nil && !merchant.AllowsCurrency(currency) {
+		respondError(c, newBancardError(http.StatusBadRequest, ErrKeyInvalidOperation, "Moneda no habilitada para el comercio: "+currency))
+		return
+	}
+
+	if err := p.checkShopProcessID(request.PublicKey, operation.ShopProcessID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	card, err := p.findCard(request.PublicKey, operation.AliasToken)
+	if err != nil {
+		respondError(c, cardNotFoundError())
+		return
+	}
+
+	profile := &cardProfile{Brand: card.Brand, Issuer: card.Meta(MetaCardIssuer)}
+	plan, planErr := p.installmentPlan(profile, operation.NumberOfPayments, amount)
+	if planErr != nil {
+		respondError(c, planErr)
+		return
+	}
+
+	tx := &store.Transaction{
+		Gateway:     GatewayName,
+		ID:          generateProcessID(),
+		Reference:   operation.ShopProcessID,
+		Amount:      amount,
+		Currency:    currency,
+		Description: operation.Description,
+	}
+	tx.SetMeta(MetaPublicKey, request.PublicKey)
+	tx.SetMeta(MetaAliasToken, card.AliasToken)
+	tx.SetMeta(MetaCardMaskedNumber, card.MaskedNumber)
+	tx.SetMeta(MetaCardBrand, profile.Brand)
+	tx.SetMeta(MetaCardIssuer, profile.Issuer)
+
+	if _, err := p.store.Create(tx); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	newStatus := store.StatusPaid
+	responseStatus := StatusSuccess
+	testCard := p.testCard(card.Meta(MetaTestCard))
+	if testCard != nil {
+		// Ante un timeout el cobro queda pendiente, igual que en el iframe
+		if timeoutErr := simulateTestCard(c.Request.Context(), testCard); timeoutErr != nil {
+			if _, err := p.store.Transition(GatewayName, tx.ID, store.StatusPending, "Timeout del procesador", nil); err != nil {
+				respondError(c, err)
+				return
+			}
+			respondError(c, timeoutErr)
+			return
+		}
+		if !isApproved(testCard) {
+			newStatus = store.StatusFailed
+			responseStatus = StatusError
+		}
+	}
+
+	customerIP := c.ClientIP()
+	tx, err = p.store.Transition(GatewayName, tx.ID, newStatus, "Cobro con tarjeta catastrada", func(tx *store.Transaction) error {
+		tx.SetMeta(MetaCustomerIP, customerIP)
+		if newStatus == store.StatusFailed {
+			setResponseCodeMeta(tx, responseCodeFor(testCard.ResponseCode, testCard.Description))
+			return nil
+		}
+		setApprovalMeta(tx)
+		setInstallmentMeta(tx, operation.NumberOfPayments, plan)
+		return nil
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	privateKey := ""
+	if merchant != nil {
+		privateKey = merchant.PrivateKey
+	}
+
+	c.JSON(http.StatusOK, BancardGetConfirmationResponse{
+		Status:       responseStatus,
+		Confirmation: buildConfirmationOperation(tx, privateKey),
+	})
+}
+
+// handleCardForm muestra el iframe de catastro de tarjeta
+func (p *BancardPlugin) handleCardForm(c *gin.Context) {
+	processID := c.Param("process_id")
+
+	card, err := p.store.GetCard(GatewayName, processID)
+	if err != nil || card.Status != store.CardPending {
+		c.HTML(http.StatusNotFound, "bancard_result.html", gin.H{
+			"result":     StatusError,
+			"process_id": processID,
+			"message":    "El proceso de catastro no existe o ya fue completado",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "bancard_card_form.html", gin.H{
+		"card":     card,
+		"styles":   checkoutStyles(c.Query("styles")),
+		"embedded": c.Query("embedded") != "",
+	})
+}
+
+// handleEmulatorCardRegistration completa el catastro con los datos cargados en el iframe
+func (p *BancardPlugin) handleEmulatorCardRegistration(c *gin.Context) {
+	processID := c.Param("process_id")
+
+	var registration BancardCardRegistration
+	if err := c.ShouldBindJSON(&registration); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+
+	card, err := p.store.GetCard(GatewayName, processID)
+	if err != nil || card.Status != store.CardPending {
+		respondError(c, newBancardError(http.StatusNotFound, ErrKeyInvalidOperation, "El proceso de catastro no existe o ya fue completado"))
+		return
+	}
+
+	query := url.Values{}
+	switch registration.Result {
+	case StatusSuccess:
+		if err := validateCard(registration.CardNumber, registration.ExpirationDate, registration.Cvv, registration.Cvv != ""); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		number := digitsOnly(registration.CardNumber)
+		testCard := p.testCard(number)
+		if testCard != nil {
+			if err := simulateTestCard(c.Request.Context(), testCard); err != nil {
+				respondError(c, err)
+				return
+			}
+		}
+
+		card, err = p.store.UpdateCard(GatewayName, processID, func(card *store.Card) error {
+			card.Status = store.CardRegistered
+			card.AliasToken = generateAliasToken()
+			card.MaskedNumber = maskCardNumber(number)
+			card.Brand = cardBrand(number)
+			card.Type = firstNonEmpty(registration.CardType, CardTypeCredit)
+			card.ExpirationDate = registration.ExpirationDate
+			// Los cobros con la tarjeta reproducen el resultado de la tarjeta de prueba
+			if testCard != nil {
+				card.SetMeta(MetaTestCard, testCard.Number)
+				card.SetMeta(MetaCardIssuer, testCard.Issuer)
+			}
+			return nil
+		})
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		query.Set("status", CardStatusSuccess)
+		query.Set("description", "Tarjeta catastrada exitosamente")
+	case StatusError:
+		query.Set("status", CardStatusFail)
+		query.Set("description", "La tarjeta no pudo ser verificada")
+	case "cancel", StatusCancelled:
+		query.Set("status", CardStatusFail)
+		query.Set("description", "Catastro cancelado por el usuario")
+	default:
+		respondError(c, newBancardError(http.StatusBadRequest, ErrKeyInvalidOperation, "Resultado de simulación inválido: "+registration.Result))
+		return
+	}
+
+	c.JSON(http.StatusOK, BancardSimulationResult{
+		Status:      registration.Result,
+		ProcessID:   processID,
+		Message:     query.Get("description"),
+		RedirectURL: appendQuery(firstNonEmpty(card.ReturnURL, DefaultReturnURL), query),
+	})
+}
+
+// merchantCards devuelve las tarjetas catastradas de un usuario para el comercio de la clave pública
+func (p *BancardPlugin) merchantCards(publicKey, userID string) []*store.Card {
+	cards := make([]*store.Card, 0)
+	for _, card := range p.store.ListCards(GatewayName, userID) {
+		if card.Status == store.CardRegistered && card.Meta(MetaPublicKey) == publicKey {
+			cards = append(cards, card)
+		}
+	}
+	return cards
+}
+
+// findCard busca una tarjeta catastrada por alias_token para el comercio de la clave pública
+func (p *BancardPlugin) findCard(publicKey, aliasToken string) (*store.Card, error) {
+	card, err := p.store.FindCardByAlias(GatewayName, aliasToken)
+	if err != nil {
+		return nil, err
+	}
+	if card.Meta(MetaPublicKey) != publicKey {
+		return nil, store.ErrCardNotFound
+	}
+	return card, nil
+}
+
+// cardNotFoundError devuelve el error de Bancard para un alias_token desconocido
+func cardNotFoundError() *BancardError {
+	return newBancardError(http.StatusNotFound, ErrKeyCardNotFound, "No existe una tarjeta con ese alias_token")
+}
+
+func generateAliasToken() string {
+	return fmt.Sprintf("%016x%016x", rand.Uint64(), rand.Uint64())
+}
+
+func digitsOnly(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+}
+
+// maskCardNumber enmascara el número dejando visibles el BIN y los últimos 4 dígitos
+func maskCardNumber(number string) string {
+	return number[:6] + strings.Repeat("*", len(number)-10) + number[len(number)-4:]
+}
+
+// cardBrand deduce la marca de la tarjeta a partir de su número
+func cardBrand(number string) string {
+	switch {
+	case strings.HasPrefix(number, "4"):
+		return BrandVisa
+	case strings.HasPrefix(number, "34"), strings.HasPrefix(number, "37"):
+		return BrandAmex
+	case strings.HasPrefix(number, "5"), strings.HasPrefix(number, "2"):
+		return BrandMastercard
+	default:
+		return "BANCARD"
+	}
+}
@@ -0,0 +1,52 @@
+package bancard
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkoutStyleKeys son las claves de estilo que acepta Bancard.Checkout.createForm
+var checkoutStyleKeys = []string{
+	"form-background-color",
+	"button-background-color",
+	"button-text-color",
+	"button-border-color",
+	"input-background-color",
+	"input-text-color",
+	"input-placeholder-color",
+}
+
+// styleValuePattern limita los valores de estilo a colores CSS (#hex, nombres, rgb() o hsl())
+var styleValuePattern = regexp.MustCompile(`^[#a-zA-Z0-9 ,.%()-]{1,64}$`)
+
+// handleCheckoutJS sirve el script compatible con bancard-checkout.js
+func (p *BancardPlugin) handleCheckoutJS(c *gin.Context) {
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(bancardCheckoutJS))
+}
+
+// checkoutStyles interpreta los estilos que bancard-checkout.js envía al iframe. Las claves
+// desconocidas y los valores que no son colores se descartan.
+func checkoutStyles(raw string) map[string]template.CSS {
+	styles := make(map[string]template.CSS)
+	if raw == "" {
+		return styles
+	}
+
+	var requested map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &requested); err != nil {
+		return styles
+	}
+
+	for _, key := range checkoutStyleKeys {
+		value, ok := requested[key].(string)
+		if ok && styleValuePattern.MatchString(value) {
+			styles[key] = template.CSS(value)
+		}
+	}
+	return styles
+}
@@ -0,0 +1,111 @@
+package bancard
+
+import (
+	"encoding/json"
+	"net/http"
+	"payment-emulator/internal/store"
+	"payment-emulator/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// buildConfirmationOperation arma la operación de confirmación a partir de la transacción almacenada
+func buildConfirmationOperation(tx *store.Transaction, privateKey string) BancardCallbackOperation {
+	amount := store.FormatAmount(tx.Amount)
+
+	operation := BancardCallbackOperation{
+		Token:               confirmationToken(privateKey, tx.Reference, amount, tx.Currency),
+		ShopProcessID:       tx.Reference,
+		Response:            ResponseApproved,
+		ResponseDetails:     "Procesado Satisfactoriamente",
+		Amount:              amount,
+		Currency:            tx.Currency,
+		AuthorizationNumber: tx.Meta(MetaAuthorizationNumber),
+		TicketNumber:        tx.Meta(MetaTicketNumber),
+		ResponseCode:        tx.Meta(MetaResponseCode),
+		ResponseDescription: tx.Meta(MetaResponseDescription),
+		NumberOfPayments:    numberOfPayments(tx),
+		SecurityInformation: BancardSecurityInformation{
+			CustomerIP:  tx.Meta(MetaCustomerIP),
+			CardSource:  "L",
+			CardCountry: "PARAGUAY",
+			Version:     "0.3",
+			RiskIndex:   0,
+		},
+	}
+
+	if tx.Status == store.StatusFailed {
+		operation.Response = ResponseDeclined
+		operation.ResponseDetails = "Transacción rechazada"
+		operation.ExtendedResponseDescription = firstNonEmpty(tx.Meta(MetaExtendedResponseDescription), tx.Meta(MetaResponseDescription))
+	}
+
+	return operation
+}
+
+// sendConfirmation envía la confirmación firmada a la URL de confirmación del comercio
+func (p *BancardPlugin) sendConfirmation(tx *store.Transaction) *webhook.Delivery {
+	merchant, ok := p.config.FindMerchant(tx.Meta(MetaPublicKey))
+	if !ok || merchant.ConfirmationURL == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(BancardConfirmationCallback{
+		Operation: buildConfirmationOperation(tx, merchant.PrivateKey),
+	})
+	if err != nil {
+		return nil
+	}
+
+	return p.webhooks.Enqueue(webhook.Request{
+		Gateway:       GatewayName,
+		Event:         WebhookEventConfirmation,
+		TransactionID: tx.ID,
+		URL:           merchant.ConfirmationURL,
+		Body:          body,
+	})
+}
+
+// handleGetConfirmation maneja la consulta de confirmación (single_buy/confirmations) que usa
+// el comercio cuando no recibió la confirmación en su URL
+func (p *BancardPlugin) handleGetConfirmation(c *gin.Context) {
+	var request BancardGetConfirmationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+
+	tx, err := p.findPurchase(request.PublicKey, request.Operation.ShopProcessID)
+	if err != nil {
+		respondError(c, paymentNotFoundError(err))
+		return
+	}
+
+	merchant, err := p.verifyToken(request.PublicKey, request.Operation.Token, func(privateKey string) string {
+		return getConfirmationToken(privateKey, request.Operation.ShopProcessID)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	switch tx.Status {
+	case store.StatusPaid, store.StatusAuthorized, store.StatusRefunded, store.StatusFailed:
+	case store.StatusCreated, store.StatusPending:
+		respondError(c, newBancardError(http.StatusBadRequest, ErrKeyPaymentNotConfirmed, "El pago aún no ha sido confirmado"))
+		return
+	default:
+		respondError(c, alreadyProcessedError(tx.Status))
+		return
+	}
+
+	privateKey := ""
+	if merchant != nil {
+		privateKey = merchant.PrivateKey
+	}
+
+	c.JSON(http.StatusOK, BancardGetConfirmationResponse{
+		Status:       StatusSuccess,
+		Confirmation: buildConfirmationOperation(tx, privateKey),
+	})
+}
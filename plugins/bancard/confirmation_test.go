@@ -0,0 +1,72 @@
+package bancard
+
+import (
+	"net/http"
+	"payment-emulator/internal/store"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// confirmation consulta la compra con el endpoint confirmation firmado por merchantA
+func (s *testServer) confirmation(shopProcessID string) BancardConfirmationResponse {
+	s.t.Helper()
+	var response BancardConfirmationResponse
+	code := s.do(http.MethodPost, "/vpos/api/0.3/confirmation", gin.H{
+		"public_key":      merchantA.PublicKey,
+		"shop_process_id": shopProcessID,
+		"token":           getConfirmationToken(merchantA.PrivateKey, shopProcessID),
+	}, &response)
+	if code != http.StatusOK {
+		s.t.Fatalf("confirmation %s = %d, want 200", shopProcessID, code)
+	}
+	return response
+}
+
+func TestConfirmationOfRefundedPurchaseIsApproved(t *testing.T) {
+	s := newTestServer(t)
+
+	processID := s.singleBuy(merchantA, "5005", "10000.00")
+	s.pay(processID)
+	authorization := s.confirmation("5005").AuthorizationNumber
+
+	code := s.do(http.MethodPost, "/vpos/api/0.3/refund", operation(merchantA, gin.H{
+		"token": refundToken(merchantA.PrivateKey, "5005", ""), "shop_process_id": "5005",
+	}), nil)
+	if code != http.StatusOK {
+		t.Fatalf("refund = %d, want 200", code)
+	}
+	if tx := s.transaction(processID); tx.Status != store.StatusRefunded {
+		t.Fatalf("purchase is %s, want %s", tx.Status, store.StatusRefunded)
+	}
+
+	response := s.confirmation("5005")
+	if response.Status != StatusSuccess || response.AuthorizationNumber == "" || response.AuthorizationNumber != authorization {
+		t.Errorf("confirmation of refunded purchase = %s authorization %q, want %s authorization %q", response.Status, response.AuthorizationNumber, StatusSuccess, authorization)
+	}
+}
+
+func TestConfirmationOfPreauthorizedPurchaseIsApproved(t *testing.T) {
+	s := newTestServer(t)
+
+	var order BancardOrderResponse
+	code := s.do(http.MethodPost, "/vpos/api/0.3/single_buy", operation(merchantA, gin.H{
+		"token":            singleBuyToken(merchantA.PrivateKey, "6006", "10000.00", CurrencyPYG),
+		"shop_process_id":  "6006",
+		"amount":           "10000.00",
+		"currency":         CurrencyPYG,
+		"preauthorization": PreauthorizationEnabled,
+	}), &order)
+	if code != http.StatusOK {
+		t.Fatalf("single_buy with preauthorization = %d, want 200", code)
+	}
+	s.pay(order.ProcessID)
+	if tx := s.transaction(order.ProcessID); tx.Status != store.StatusAuthorized {
+		t.Fatalf("purchase is %s, want %s", tx.Status, store.StatusAuthorized)
+	}
+
+	response := s.confirmation("6006")
+	if response.Status != StatusSuccess || response.AuthorizationNumber == "" {
+		t.Errorf("confirmation of preauthorized purchase = %s authorization %q, want %s with authorization", response.Status, response.AuthorizationNumber, StatusSuccess)
+	}
+}
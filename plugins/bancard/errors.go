@@ -0,0 +1,106 @@
+package bancard
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"payment-emulator/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// Claves de error oficiales de Bancard VPOS
+const (
+	ErrKeyInvalidToken        = "InvalidTokenError"
+	ErrKeyInvalidPublicKey    = "InvalidPublicKeyError"
+	ErrKeyInvalidOperation    = "InvalidOperationError"
+	ErrKeyInvalidJSON         = "InvalidJsonError"
+	ErrKeyPaymentNotFound     = "PaymentNotFoundError"
+	ErrKeyInvalidAmount       = "InvalidAmountError"
+	ErrKeyCardNotFound        = "CardNotFoundError"
+	ErrKeyInvalidCardNumber   = "InvalidCardNumberError"
+	ErrKeyInvalidExpiration   = "InvalidExpirationDateError"
+	ErrKeyInvalidCvv          = "InvalidCvvError"
+	ErrKeyTimeout             = "TimeoutError"
+	ErrKeyInvalidInstallments = "InvalidInstallmentsError"
+	ErrKeyInvalidPromotion    = "InvalidPromotionCodeError"
+	ErrKeyInvalidPhone        = "InvalidPhoneNumberError"
+	ErrKeyInvalidPin          = "InvalidPinError"
+	ErrKeyInvalidCredentials  = "InvalidCredentialsError"
+	ErrKeyBuyNotFound         = "BuyNotFoundError"
+	ErrKeyDuplicatedProcessID = "DuplicatedShopProcessIdError"
+	ErrKeyAlreadyConfirmed    = "TransactionAlreadyConfirmed"
+	ErrKeyAlreadyRollbacked   = "AlreadyRollbackedError"
+	ErrKeyPaymentNotConfirmed = "PaymentNotConfirmedError"
+	ErrKeyInternalServerError = "InternalServerError"
+)
+
+// BancardError representa un error de negocio de la API de Bancard
+type BancardError struct {
+	Key        string
+	Dsc        string
+	HTTPStatus int
+}
+
+func (e *BancardError) Error() string {
+	return e.Key + ": " + e.Dsc
+}
+
+// BancardMessage representa un mensaje dentro de una respuesta de Bancard
+type BancardMessage struct {
+	Key   string `json:"key"`
+	Level string `json:"level"`
+	Dsc   string `json:"dsc"`
+}
+
+// BancardErrorResponse representa la respuesta de error de Bancard
+type BancardErrorResponse struct {
+	Status   string           `json:"status"`
+	Messages []BancardMessage `json:"messages"`
+}
+
+func newBancardError(httpStatus int, key, dsc string) *BancardError {
+	return &BancardError{Key: key, Dsc: dsc, HTTPStatus: httpStatus}
+}
+
+// respondError responde con el formato de error de Bancard
+func respondError(c *gin.Context, err error) {
+	var bancardErr *BancardError
+	if !errors.As(err, &bancardErr) {
+		bancardErr = newBancardError(http.StatusInternalServerError, ErrKeyInternalServerError, err.Error())
+	}
+
+	c.JSON(bancardErr.HTTPStatus, BancardErrorResponse{
+		Status: StatusError,
+		Messages: []BancardMessage{
+			{Key: bancardErr.Key, Level: StatusError, Dsc: bancardErr.Dsc},
+		},
+	})
+}
+
+// bindingError traduce un error de c.ShouldBindJSON a la clave oficial de Bancard. Los campos
+// obligatorios de credenciales y monto usan su propia clave; el resto es InvalidJsonError.
+func bindingError(err error) *BancardError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) && len(validationErrs) > 0 {
+		field := validationErrs[0]
+		dsc := plugins.JSONFieldPath(field.Namespace()) + " es obligatorio"
+		switch field.StructField() {
+		case "PublicKey":
+			return newBancardError(http.StatusUnauthorized, ErrKeyInvalidPublicKey, dsc)
+		case "Token":
+			return newBancardError(http.StatusUnauthorized, ErrKeyInvalidToken, dsc)
+		case "Amount":
+			return newBancardError(http.StatusBadRequest, ErrKeyInvalidAmount, dsc)
+		}
+		return newBancardError(http.StatusBadRequest, ErrKeyInvalidJSON, dsc)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return newBancardError(http.StatusBadRequest, ErrKeyInvalidJSON, "El campo "+typeErr.Field+" debe ser de tipo "+typeErr.Type.String())
+	}
+
+	return newBancardError(http.StatusBadRequest, ErrKeyInvalidJSON, "El cuerpo de la petición no es un JSON válido")
+}
@@ -1,10 +1,16 @@
 package bancard
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"payment-emulator/internal/plugins"
+	"payment-emulator/internal/store"
+	"payment-emulator/internal/webhook"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,6 +20,8 @@ type BancardPlugin struct {
 	name       string
 	pluginType string
 	config     *plugins.Plugin
+	store      *store.Store
+	webhooks   *webhook.Dispatcher
 }
 
 // NewBancardPlugin crea una nueva instancia del plugin de Bancard
@@ -22,6 +30,8 @@ func NewBancardPlugin(config *plugins.Plugin) plugins.PaymentPlugin {
 		name:       "Bancard VPOS",
 		pluginType: "iframe",
 		config:     config,
+		store:      store.GetGlobalStore(),
+		webhooks:   webhook.GetGlobalDispatcher(),
 	}
 }
 
@@ -63,9 +73,30 @@ func (p *BancardPlugin) setupAPIRoutes(r *gin.Engine) {
 	{
 		v03.POST("/single_buy", p.handleSingleBuy)
 		v03.POST("/confirmation", p.handleConfirmation)
+		v03.POST("/single_buy/rollback", p.handleRollback)
+		v03.POST("/single_buy/confirmations", p.handleGetConfirmation)
+		v03.POST("/refund", p.handleRefund)
+
+		// Catastro de tarjetas
+		v03.POST("/cards/new", p.handleNewCard)
+		v03.POST("/users/:user_id/cards", p.handleUserCards)
+		v03.DELETE("/users/:user_id/cards", p.handleDeleteCard)
+		v03.POST("/charge", p.handleCharge)
+
+		// Preautorizaciones
+		v03.POST("/preauthorizations/confirm", p.handlePreauthorizationConfirm)
+		v03.POST("/preauthorizations/rollback", p.handlePreauthorizationRollback)
 		v03.GET("/single_buy/:process_id", p.handleGetTransaction)
 	}
 
+	// Pagos QR (API de comercios externos, autenticación Basic)
+	qr := r.Group("/external-commerce/api/0.1/commerces/:commerce_code/branches/:branch_code/selling")
+	{
+		qr.POST("/generate-qr-express", p.handleGenerateQR)
+		qr.GET("/payments/:hook_alias", p.handleGetQRPayment)
+		qr.DELETE("/payments/revert/:hook_alias", p.handleRevertQRPayment)
+	}
+
 	// API legacy
 	r.POST("/bancard/single_buy", p.handleSingleBuy)
 	r.POST("/bancard/confirmation", p.handleConfirmation)
@@ -74,6 +105,11 @@ func (p *BancardPlugin) setupAPIRoutes(r *gin.Engine) {
 // setupCheckoutRoutes configura las rutas del checkout
 func (p *BancardPlugin) setupCheckoutRoutes(r *gin.Engine) {
 	r.GET("/bancard/checkout/:process_id", p.handleCheckout)
+	r.GET("/checkout/javascript/dist/:file", p.handleCheckoutJS)
+	r.GET("/bancard-checkout.js", p.handleCheckoutJS)
+	r.GET("/bancard/cards/new/:process_id", p.handleCardForm)
+	r.GET("/bancard/qr/:hook_alias", p.handleQRPage)
+	r.GET("/bancard/qr-clients/:logo", p.handleQRClientLogo)
 	r.GET("/bancard/return", p.handleReturn)
 	r.GET("/bancard/cancel", p.handleCancel)
 }
@@ -81,47 +117,112 @@ func (p *BancardPlugin) setupCheckoutRoutes(r *gin.Engine) {
 // setupEmulatorRoutes configura las rutas del emulador
 func (p *BancardPlugin) setupEmulatorRoutes(r *gin.Engine) {
 	r.POST("/emulator/bancard/:process_id", p.handleEmulatorPayment)
+	r.POST("/emulator/bancard/cards/:process_id", p.handleEmulatorCardRegistration)
 	r.GET("/emulator/bancard/result", p.handleEmulatorResult)
+	r.GET("/emulator/bancard/response-codes", p.handleEmulatorResponseCodes)
+	r.GET("/emulator/bancard/qr/:hook_alias", p.handleEmulatorQR)
+	r.POST("/emulator/bancard/qr/:hook_alias/scan", p.handleEmulatorQRScan)
 }
 
 // handleSingleBuy maneja la creación de una transacción de compra simple
 func (p *BancardPlugin) handleSingleBuy(c *gin.Context) {
 	var request BancardOrderRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, BancardOrderResponse{
-			Status:  StatusError,
-			Message: "Datos JSON inválidos: " + err.Error(),
-		})
+		respondError(c, bindingError(err))
 		return
 	}
 
-	// Validar campos obligatorios
-	if request.PublicKey == "" {
-		c.JSON(http.StatusBadRequest, BancardOrderResponse{
-			Status:  StatusError,
-			Message: "public_key es obligatorio",
-		})
+	amount, err := store.ParseAmount(request.Operation.Amount)
+	if err != nil || amount <= 0 {
+		respondError(c, newBancardError(http.StatusBadRequest, ErrKeyInvalidAmount, "operation.amount inválido: "+request.Operation.Amount))
 		return
 	}
 
-	if request.Operation.Token == "" {
-		c.JSON(http.StatusBadRequest, BancardOrderResponse{
-			Status:  StatusError,
-			Message: "operation.token es obligatorio",
-		})
+	currency := request.Operation.Currency
+	if currency == "" {
+		currency = CurrencyPYG
+	}
+
+	merchant, err := p.verifyToken(request.PublicKey, request.Operation.Token, func(privateKey string) string {
+		return singleBuyToken(privateKey, request.Operation.ShopProcessID, request.Operation.Amount, currency)
+	})
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	if request.Operation.Amount == "" {
-		c.JSON(http.StatusBadRequest, BancardOrderResponse{
-			Status:  StatusError,
-			Message: "operation.amount es obligatorio",
-		})
+	if merchant != nil && !merchant.AllowsCurrency(currency) {
+		respondError(c, newBancardError(http.StatusBadRequest, ErrKeyInvalidOperation, "Moneda no habilitada para el comercio: "+currency))
+		return
+	}
+
+	if err := p.checkShopProcessID(request.PublicKey, request.Operation.ShopProcessID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	operation := request.Operation
+	zimple := operation.Zimple == ZimpleEnabled
+	if zimple && operation.NumberOfPayments > 1 {
+		respondError(c, newBancardError(http.StatusBadRequest, ErrKeyInvalidInstallments, "Los pagos con Zimple no admiten cuotas"))
 		return
 	}
+	if zimple && zimplePhone(operation) != "" {
+		if err := validateZimplePhone(zimplePhone(operation)); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	if operation.NumberOfPayments > 1 {
+		if _, err := p.offeredInstallmentPlan(operation.NumberOfPayments, amount); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
 
-	// Generar ProcessID único
+	promotion := promotionCode(operation)
+	if promotion != "" {
+		found, ok := p.findPromotion(promotion)
+		if !ok {
+			respondError(c, newBancardError(http.StatusBadRequest, ErrKeyInvalidPromotion, "Código de promoción inválido: "+promotion))
+			return
+		}
+		promotion = found.Code
+	}
+
+	// Generar ProcessID único y registrar la transacción
 	processID := generateProcessID()
+	tx := &store.Transaction{
+		Gateway:     GatewayName,
+		ID:          processID,
+		Reference:   request.Operation.ShopProcessID,
+		Amount:      amount,
+		Currency:    currency,
+		Description: request.Operation.Description,
+		ReturnURL:   firstNonEmpty(request.Operation.ReturnURL, request.ReturnURL),
+		CancelURL:   firstNonEmpty(request.Operation.CancelURL, request.CancelURL),
+	}
+	tx.SetMeta(MetaPublicKey, request.PublicKey)
+	if request.Operation.Preauthorization == PreauthorizationEnabled {
+		tx.SetMeta(MetaPreauthorization, PreauthorizationEnabled)
+	}
+	if operation.NumberOfPayments > 1 {
+		tx.SetMeta(MetaNumberOfPayments, strconv.Itoa(operation.NumberOfPayments))
+	}
+	if promotion != "" {
+		tx.SetMeta(MetaPromotionCode, promotion)
+	}
+	if zimple {
+		tx.SetMeta(MetaZimple, ZimpleEnabled)
+		tx.SetMeta(MetaZimplePhone, zimplePhone(operation))
+	}
+
+	if _, err := p.store.Create(tx); err != nil {
+		respondError(c, err)
+		return
+	}
+
 	redirectURL := fmt.Sprintf("/bancard/checkout/%s", processID)
 
 	response := BancardOrderResponse{
@@ -138,46 +239,73 @@ func (p *BancardPlugin) handleSingleBuy(c *gin.Context) {
 func (p *BancardPlugin) handleConfirmation(c *gin.Context) {
 	var request BancardConfirmationRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, BancardConfirmationResponse{
-			Status:  StatusError,
-			Message: "Datos JSON inválidos: " + err.Error(),
-		})
+		respondError(c, bindingError(err))
+		return
+	}
+	token := firstNonEmpty(request.Operation.Token, request.Token)
+	if token == "" {
+		respondError(c, newBancardError(http.StatusUnauthorized, ErrKeyInvalidToken, "operation.token es obligatorio"))
 		return
 	}
 
-	// Validar campos obligatorios
-	if request.ShopProcessID == "" || request.Token == "" {
-		c.JSON(http.StatusBadRequest, BancardConfirmationResponse{
-			Status:  StatusError,
-			Message: "shop_process_id y token son obligatorios",
-		})
+	// Sin public_key (API legacy) la compra es la del comercio que firmó el token
+	publicKey := request.PublicKey
+	if publicKey == "" {
+		publicKey = p.confirmationOwner(request.ShopProcessID, token)
+	}
+
+	tx, err := p.findPurchase(publicKey, request.ShopProcessID)
+	if err != nil {
+		respondError(c, paymentNotFoundError(err))
 		return
 	}
 
-	// Simular confirmación exitosa
+	_, err = p.verifyToken(publicKey, token, func(privateKey string) string {
+		return getConfirmationToken(privateKey, request.ShopProcessID)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	switch tx.Status {
+	case store.StatusCreated, store.StatusPending:
+		respondError(c, newBancardError(http.StatusBadRequest, ErrKeyPaymentNotConfirmed, "El pago aún no ha sido confirmado"))
+		return
+	case store.StatusCancelled, store.StatusExpired, store.StatusReversed:
+		respondError(c, alreadyProcessedError(tx.Status))
+		return
+	}
+
+	c.JSON(http.StatusOK, buildConfirmationResponse(tx))
+}
+
+// buildConfirmationResponse arma la respuesta de confirmación a partir de la transacción almacenada
+func buildConfirmationResponse(tx *store.Transaction) BancardConfirmationResponse {
 	response := BancardConfirmationResponse{
 		Status:              StatusSuccess,
 		Message:             "Transacción confirmada exitosamente",
-		TransactionID:       generateTransactionID(),
-		AuthorizationNumber: generateAuthNumber(),
-		TicketNumber:        generateTicketNumber(),
-		ResponseCode:        "00",
-		ResponseDescription: "Transacción aprobada",
-		Amount:              "100000",
-		Currency:            CurrencyPYG,
+		TransactionID:       tx.Meta(MetaTransactionID),
+		AuthorizationNumber: tx.Meta(MetaAuthorizationNumber),
+		TicketNumber:        tx.Meta(MetaTicketNumber),
+		ResponseCode:        tx.Meta(MetaResponseCode),
+		ResponseDescription: tx.Meta(MetaResponseDescription),
+		NumberOfPayments:    numberOfPayments(tx),
+		Amount:              store.FormatAmount(tx.Amount),
+		Currency:            tx.Currency,
 		Security: BancardSecurityInfo{
 			Customer: BancardCustomerInfo{
-				Document:     "12345678",
-				DocumentType: "CI",
-				Email:        "test@example.com",
-				CellPhone:    "+595981234567",
+				Document:     tx.Buyer.Document,
+				DocumentType: tx.Buyer.DocumentType,
+				Email:        tx.Buyer.Email,
+				CellPhone:    tx.Buyer.Phone,
 			},
 			CardInfo: BancardCardInfo{
 				Bin:           "450000",
 				Last4:         "1234",
-				Brand:         BrandVisa,
+				Brand:         firstNonEmpty(tx.Meta(MetaCardBrand), BrandVisa),
 				Type:          CardTypeCredit,
-				Issuer:        "Banco Test",
+				Issuer:        firstNonEmpty(tx.Meta(MetaCardIssuer), "Banco Test"),
 				IssuerCountry: "PY",
 			},
 			RiskAnalysis: BancardRiskAnalysis{
@@ -187,30 +315,45 @@ func (p *BancardPlugin) handleConfirmation(c *gin.Context) {
 		},
 	}
 
-	c.JSON(http.StatusOK, response)
+	// Una compra reembolsada o preautorizada fue aprobada: se informa con su autorización original
+	if tx.Status == store.StatusFailed {
+		response.Status = StatusError
+		response.Message = fmt.Sprintf("La transacción no fue aprobada (estado: %s)", tx.Status)
+		response.ExtendedResponseDescription = tx.Meta(MetaExtendedResponseDescription)
+	}
+
+	return response
 }
 
 // handleGetTransaction maneja la consulta de una transacción por ID
 func (p *BancardPlugin) handleGetTransaction(c *gin.Context) {
 	processID := c.Param("process_id")
 
-	if processID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  StatusError,
-			"message": "process_id es obligatorio",
-		})
+	tx, err := p.store.Get(GatewayName, processID)
+	if err != nil {
+		respondError(c, newBancardError(http.StatusNotFound, ErrKeyBuyNotFound, "No existe una transacción con process_id "+processID))
 		return
 	}
 
-	// Simular información de transacción
 	response := gin.H{
-		"status":         StatusSuccess,
-		"process_id":     processID,
-		"transaction_id": generateTransactionID(),
-		"amount":         "100000",
-		"currency":       CurrencyPYG,
-		"state":          "confirmed",
-		"created_at":     "2025-09-16T12:30:45Z",
+		"status":             StatusSuccess,
+		"process_id":         tx.ID,
+		"shop_process_id":    tx.Reference,
+		"transaction_id":     tx.Meta(MetaTransactionID),
+		"amount":             store.FormatAmount(tx.Amount),
+		"currency":           tx.Currency,
+		"state":              tx.Status,
+		"refunded_amount":    store.FormatAmount(refundedAmount(tx)),
+		"held_amount":        tx.Meta(MetaHeldAmount),
+		"number_of_payments": tx.Meta(MetaNumberOfPayments),
+		"installment_amount": tx.Meta(MetaInstallmentAmount),
+		"interest_rate":      tx.Meta(MetaInterestRate),
+		"promotion_code":     tx.Meta(MetaPromotionCode),
+		"original_amount":    tx.Meta(MetaOriginalAmount),
+		"discount_amount":    tx.Meta(MetaDiscountAmount),
+		"history":            tx.History,
+		"created_at":         tx.CreatedAt,
+		"updated_at":         tx.UpdatedAt,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -220,22 +363,71 @@ func (p *BancardPlugin) handleGetTransaction(c *gin.Context) {
 func (p *BancardPlugin) handleCheckout(c *gin.Context) {
 	processID := c.Param("process_id")
 
+	tx, err := p.store.Get(GatewayName, processID)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "bancard_result.html", gin.H{
+			"result":     StatusError,
+			"process_id": processID,
+			"message":    "La transacción no existe o ya expiró",
+		})
+		return
+	}
+
+	switch tx.Status {
+	case store.StatusCreated:
+		// Al abrir el iframe la transacción queda pendiente de pago
+		if pending, err := p.store.Transition(GatewayName, processID, store.StatusPending, "Checkout abierto", nil); err == nil {
+			tx = pending
+		}
+	case store.StatusPending:
+	default:
+		c.HTML(http.StatusConflict, "bancard_result.html", gin.H{
+			"result":     StatusError,
+			"process_id": processID,
+			"message":    alreadyProcessedError(tx.Status).Dsc,
+		})
+		return
+	}
+
+	description := tx.Description
+	if description == "" {
+		description = "Compra - Bancard VPOS"
+	}
+
+	var promotion *plugins.Promotion
+	if code := tx.Meta(MetaPromotionCode); code != "" {
+		promotion, _ = p.findPromotion(code)
+	}
+
+	amount := store.FormatAmount(tx.Amount)
 	checkoutData := BancardCheckoutData{
-		ProcessID:     processID,
-		Amount:        "100000",
-		Currency:      CurrencyPYG,
-		ShopProcessID: processID,
-		ReturnURL:     c.Query("return_url"),
-		CancelURL:     c.Query("cancel_url"),
+		ProcessID:     tx.ID,
+		Amount:        amount,
+		Currency:      tx.Currency,
+		ShopProcessID: tx.Reference,
+		ReturnURL:     firstNonEmpty(tx.ReturnURL, c.Query("return_url")),
+		CancelURL:     firstNonEmpty(tx.CancelURL, c.Query("cancel_url")),
 		OrderDetails: BancardOrderDetails{
-			Amount:      "100000",
-			Currency:    CurrencyPYG,
-			Description: "Compra de ejemplo - Bancard VPOS",
+			Amount:      amount,
+			Currency:    tx.Currency,
+			Description: description,
 		},
 	}
 
-	c.HTML(http.StatusOK, "bancard_checkout.html", gin.H{
-		"data": checkoutData,
+	page := "bancard_checkout.html"
+	if isZimple(tx) {
+		page = "bancard_zimple.html"
+	}
+
+	c.HTML(http.StatusOK, page, gin.H{
+		"data":           checkoutData,
+		"response_codes": declineCodes(),
+		"styles":         checkoutStyles(c.Query("styles")),
+		"installments":   p.installmentOptions(tx.Amount, tx.Currency),
+		"preset":         tx.Meta(MetaNumberOfPayments),
+		"promotion":      promotion,
+		"phone":          tx.Meta(MetaZimplePhone),
+		"embedded":       c.Query("embedded") != "",
 	})
 }
 
@@ -243,12 +435,28 @@ func (p *BancardPlugin) handleCheckout(c *gin.Context) {
 func (p *BancardPlugin) handleReturn(c *gin.Context) {
 	transactionID := c.Query("transaction_id")
 	status := c.Query("status")
+	processID := c.Query("process_id")
+
+	result := StatusSuccess
+	message := "Transacción completada exitosamente"
+	var responseCode *ResponseCode
+	if status == StatusError {
+		result = StatusError
+		message = "La transacción fue rechazada"
+		if code := c.Query("error_code"); code != "" {
+			declined := responseCodeFor(code, "")
+			responseCode = &declined
+			message = firstNonEmpty(declined.Description, message)
+		}
+	}
 
 	c.HTML(http.StatusOK, "bancard_result.html", gin.H{
 		"status":         status,
 		"transaction_id": transactionID,
-		"result":         StatusSuccess,
-		"message":        "Transacción completada exitosamente",
+		"process_id":     processID,
+		"result":         result,
+		"message":        message,
+		"response_code":  responseCode,
 	})
 }
 
@@ -266,28 +474,195 @@ func (p *BancardPlugin) handleEmulatorPayment(c *gin.Context) {
 	processID := c.Param("process_id")
 	result := c.Query("result")
 
+	tx, err := p.store.Get(GatewayName, processID)
+	if err != nil {
+		respondError(c, newBancardError(http.StatusNotFound, ErrKeyBuyNotFound, "No existe una transacción con process_id "+processID))
+		return
+	}
+
 	var status string
 	var message string
-	var transactionID string
+	var redirectURL string
+	var newStatus store.Status
 
 	switch result {
 	case StatusSuccess:
 		status = StatusSuccess
 		message = "Pago procesado exitosamente"
-		transactionID = generateTransactionID()
+		newStatus = store.StatusPaid
+		redirectURL = firstNonEmpty(tx.ReturnURL, DefaultReturnURL)
 	case StatusError:
 		status = StatusError
 		message = "Error al procesar el pago"
-	default:
+		newStatus = store.StatusFailed
+		redirectURL = firstNonEmpty(tx.ReturnURL, DefaultReturnURL)
+	case "cancel", StatusCancelled:
 		status = StatusCancelled
 		message = "Pago cancelado"
+		newStatus = store.StatusCancelled
+		redirectURL = firstNonEmpty(tx.CancelURL, DefaultCancelURL)
+	default:
+		respondError(c, newBancardError(http.StatusBadRequest, ErrKeyInvalidOperation, "Resultado de simulación inválido: "+result))
+		return
+	}
+
+	// Los datos de tarjeta son opcionales: sin ellos el resultado lo decide el botón de simulación
+	var card BancardCardData
+	if err := c.ShouldBindJSON(&card); err != nil && !errors.Is(err, io.EOF) {
+		respondError(c, bindingError(err))
+		return
+	}
+
+	// El rechazo simulado puede elegir cualquier código del catálogo (?response_code=51)
+	decline := responseCodeFor(DefaultDeclineCode, "")
+	if code := c.Query("response_code"); code != "" && newStatus == store.StatusFailed {
+		responseCode, ok := findResponseCode(code)
+		if !ok || responseCode.Approved() {
+			respondError(c, newBancardError(http.StatusBadRequest, ErrKeyInvalidOperation, "Código de respuesta de rechazo inválido: "+code))
+			return
+		}
+		decline = responseCode
+	}
+
+	// Cuotas elegidas en el checkout o preseleccionadas por el comercio en single_buy
+	installments := card.NumberOfPayments
+	if installments == 0 {
+		installments, _ = strconv.Atoi(tx.Meta(MetaNumberOfPayments))
+	}
+
+	maskedNumber := ""
+	zimplePhone := ""
+	var profile *cardProfile
+	var plan *plugins.InstallmentPlan
+	if newStatus == store.StatusPaid {
+		var testCard *plugins.TestCard
+		if isZimple(tx) {
+			// Zimple confirma con el PIN de la billetera; sin PIN (simulación por API) se aprueba
+			profile = &cardProfile{Brand: BrandZimple}
+			installments = 1
+			zimplePhone = firstNonEmpty(digitsOnly(card.Phone), tx.Meta(MetaZimplePhone))
+			if card.Pin != "" {
+				zimpleDecline, err := simulateZimple(zimplePhone, card.Pin)
+				if err != nil {
+					respondError(c, err)
+					return
+				}
+				if zimpleDecline != nil {
+					status = StatusError
+					newStatus = store.StatusFailed
+					decline = *zimpleDecline
+				}
+			}
+		} else if card.CardNumber != "" {
+			if err := validateCard(card.CardNumber, card.ExpirationDate, card.Cvv, true); err != nil {
+				respondError(c, err)
+				return
+			}
+			number := digitsOnly(card.CardNumber)
+			maskedNumber = maskCardNumber(number)
+			profile = &cardProfile{Brand: cardBrand(number)}
+			if testCard = p.testCard(number); testCard != nil {
+				profile.Issuer = testCard.Issuer
+			}
+		}
+
+		// Las cuotas se validan antes de enviar la operación al procesador
+		var planErr *BancardError
+		if plan, planErr = p.installmentPlan(profile, installments, tx.Amount); planErr != nil {
+			respondError(c, planErr)
+			return
+		}
+
+		if testCard != nil {
+			// Ante un timeout la transacción queda pendiente y no se envía confirmación
+			if timeoutErr := simulateTestCard(c.Request.Context(), testCard); timeoutErr != nil {
+				if tx.Status == store.StatusCreated {
+					if _, err := p.store.Transition(GatewayName, processID, store.StatusPending, "Timeout del procesador", nil); err != nil {
+						respondError(c, err)
+						return
+					}
+				}
+				respondError(c, timeoutErr)
+				return
+			}
+			if !isApproved(testCard) {
+				status = StatusError
+				newStatus = store.StatusFailed
+				decline = responseCodeFor(testCard.ResponseCode, testCard.Description)
+			}
+		}
+	}
+
+	if newStatus == store.StatusFailed {
+		message = decline.Description
+	}
+
+	// Una preautorización aprobada solo retiene los fondos
+	if newStatus == store.StatusPaid && isPreauthorization(tx) {
+		newStatus = store.StatusAuthorized
+		message = "Preautorización aprobada, fondos retenidos"
+	}
+
+	customerIP := c.ClientIP()
+	promotionApplied := false
+	tx, err = p.store.Transition(GatewayName, processID, newStatus, "Simulación desde el checkout", func(tx *store.Transaction) error {
+		tx.SetMeta(MetaCustomerIP, customerIP)
+		if maskedNumber != "" {
+			tx.SetMeta(MetaCardMaskedNumber, maskedNumber)
+		}
+		if profile != nil {
+			tx.SetMeta(MetaCardBrand, profile.Brand)
+			tx.SetMeta(MetaCardIssuer, profile.Issuer)
+		}
+		if zimplePhone != "" {
+			tx.SetMeta(MetaZimplePhone, zimplePhone)
+		}
+		switch newStatus {
+		case store.StatusPaid, store.StatusAuthorized:
+			// El descuento se aplica antes de registrar los montos aprobados
+			promotionApplied = p.applyPromotion(tx, profile)
+			setApprovalMeta(tx)
+			setInstallmentMeta(tx, installments, plan)
+			if newStatus == store.StatusAuthorized {
+				tx.SetMeta(MetaHeldAmount, store.FormatAmount(tx.Amount))
+			}
+		case store.StatusFailed:
+			setResponseCodeMeta(tx, decline)
+		}
+		return nil
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if promotionApplied {
+		message = fmt.Sprintf("%s (promoción %s, descuento de %s)", message, tx.Meta(MetaPromotionCode), tx.Meta(MetaDiscountAmount))
+	}
+
+	query := url.Values{}
+	query.Set("status", status)
+	query.Set("process_id", processID)
+	if transactionID := tx.Meta(MetaTransactionID); transactionID != "" && newStatus != store.StatusFailed {
+		query.Set("transaction_id", transactionID)
+	}
+	if newStatus == store.StatusFailed {
+		query.Set("error_code", tx.Meta(MetaResponseCode))
+	}
+
+	// Bancard notifica al comercio el resultado del pago (aprobado o rechazado)
+	var delivery *webhook.Delivery
+	if newStatus != store.StatusCancelled {
+		delivery = p.sendConfirmation(tx)
 	}
 
 	simulationResult := BancardSimulationResult{
-		Status:        status,
-		ProcessID:     processID,
-		Message:       message,
-		TransactionID: transactionID,
+		Status:               status,
+		ProcessID:            processID,
+		Message:              message,
+		RedirectURL:          appendQuery(redirectURL, query),
+		TransactionID:        tx.Meta(MetaTransactionID),
+		ConfirmationDelivery: delivery,
 	}
 
 	c.JSON(http.StatusOK, simulationResult)
@@ -307,6 +682,49 @@ func (p *BancardPlugin) handleEmulatorResult(c *gin.Context) {
 
 // Funciones auxiliares
 
+// setApprovalMeta registra en la transacción los datos de autorización de un pago aprobado
+func setApprovalMeta(tx *store.Transaction) {
+	tx.SetMeta(MetaTransactionID, generateTransactionID())
+	tx.SetMeta(MetaAuthorizationNumber, generateAuthNumber())
+	tx.SetMeta(MetaTicketNumber, generateTicketNumber())
+	setResponseCodeMeta(tx, responseCodeFor(ResponseCodeApproved, ""))
+}
+
+// findPurchase busca la compra de un shop_process_id dentro del comercio de la clave pública.
+// Cada comercio numera sus compras, así que la compra de otro comercio no se encuentra.
+func (p *BancardPlugin) findPurchase(publicKey, shopProcessID string) (*store.Transaction, error) {
+	return p.store.Find(GatewayName, func(tx *store.Transaction) bool {
+		return tx.Reference == shopProcessID && tx.Meta(MetaPublicKey) == publicKey
+	})
+}
+
+// confirmationOwner devuelve la clave pública del comercio cuya compra shop_process_id firma el
+// token de consulta. En modo permisivo, si ningún comercio lo firma, devuelve el de la primera compra.
+func (p *BancardPlugin) confirmationOwner(shopProcessID, token string) string {
+	owner := ""
+	for _, tx := range p.store.List(GatewayName) {
+		if tx.Reference != shopProcessID {
+			continue
+		}
+		publicKey := tx.Meta(MetaPublicKey)
+		if merchant, ok := p.config.FindMerchant(publicKey); ok && getConfirmationToken(merchant.PrivateKey, shopProcessID) == token {
+			return publicKey
+		}
+		if owner == "" && p.config.IsLenient() {
+			owner = publicKey
+		}
+	}
+	return owner
+}
+
+// checkShopProcessID rechaza un shop_process_id ya usado por otra compra del comercio
+func (p *BancardPlugin) checkShopProcessID(publicKey, shopProcessID string) *BancardError {
+	if _, err := p.findPurchase(publicKey, shopProcessID); err == nil {
+		return newBancardError(http.StatusBadRequest, ErrKeyDuplicatedProcessID, "Ya existe una compra con shop_process_id "+shopProcessID)
+	}
+	return nil
+}
+
 func generateProcessID() string {
 	return fmt.Sprintf("proc_%d_%d", rand.Int63(), rand.Intn(1000))
 }
@@ -322,3 +740,27 @@ func generateAuthNumber() string {
 func generateTicketNumber() string {
 	return fmt.Sprintf("TKT%08d", rand.Intn(100000000))
 }
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func appendQuery(rawURL string, query url.Values) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	current := parsed.Query()
+	for k, values := range query {
+		for _, v := range values {
+			current.Set(k, v)
+		}
+	}
+	parsed.RawQuery = current.Encode()
+	return parsed.String()
+}
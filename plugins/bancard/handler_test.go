@@ -0,0 +1,234 @@
+package bancard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"payment-emulator/internal/plugins"
+	"payment-emulator/internal/store"
+	"payment-emulator/internal/webhook"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Comercios de prueba: cada test opera con dos comercios para verificar el aislamiento entre ellos
+var (
+	merchantA = plugins.Merchant{Name: "Comercio A", PublicKey: "pk_comercio_a", PrivateKey: "sk_comercio_a"}
+	merchantB = plugins.Merchant{Name: "Comercio B", PublicKey: "pk_comercio_b", PrivateKey: "sk_comercio_b"}
+)
+
+// testServer es el plugin de Bancard montado sobre un store propio
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	plugin *BancardPlugin
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	transactions := store.NewStore()
+	transactions.RegisterLifecycle(GatewayName, bancardLifecycle)
+
+	plugin := &BancardPlugin{
+		name:       "Bancard VPOS",
+		pluginType: "iframe",
+		config:     &plugins.Plugin{Name: "Bancard VPOS", Merchants: []plugins.Merchant{merchantA, merchantB}},
+		store:      transactions,
+		webhooks:   webhook.NewDispatcher(webhook.DefaultConfig, 1),
+	}
+
+	router := gin.New()
+	plugin.SetupRoutes(router)
+	return &testServer{t: t, router: router, plugin: plugin}
+}
+
+// do envía la petición y decodifica la respuesta JSON en out (si no es nil)
+func (s *testServer) do(method, path string, body interface{}, out interface{}) int {
+	s.t.Helper()
+	return s.serve(s.newRequest(method, path, body), out)
+}
+
+// newRequest arma una petición con el cuerpo JSON indicado
+func (s *testServer) newRequest(method, path string, body interface{}) *http.Request {
+	s.t.Helper()
+
+	var reader *bytes.Reader
+	if body == nil {
+		reader = bytes.NewReader(nil)
+	} else {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("marshal %s: %v", path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	request := httptest.NewRequest(method, path, reader)
+	request.Header.Set("Content-Type", "application/json")
+	return request
+}
+
+// serve ejecuta la petición y decodifica la respuesta JSON en out (si no es nil)
+func (s *testServer) serve(request *http.Request, out interface{}) int {
+	s.t.Helper()
+
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, request)
+
+	if out != nil {
+		if err := json.Unmarshal(recorder.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s: invalid JSON response %q: %v", request.Method, request.URL.Path, recorder.Body.String(), err)
+		}
+	}
+	return recorder.Code
+}
+
+// operation arma el cuerpo {"public_key": ..., "operation": {...}} de la API de vPOS
+func operation(merchant plugins.Merchant, fields gin.H) gin.H {
+	return gin.H{"public_key": merchant.PublicKey, "operation": fields}
+}
+
+// singleBuy crea una compra del comercio y devuelve su process_id
+func (s *testServer) singleBuy(merchant plugins.Merchant, shopProcessID, amount string) string {
+	s.t.Helper()
+	var response BancardOrderResponse
+	code := s.do(http.MethodPost, "/vpos/api/0.3/single_buy", operation(merchant, gin.H{
+		"token":           singleBuyToken(merchant.PrivateKey, shopProcessID, amount, CurrencyPYG),
+		"shop_process_id": shopProcessID,
+		"amount":          amount,
+		"currency":        CurrencyPYG,
+	}), &response)
+	if code != http.StatusOK {
+		s.t.Fatalf("single_buy %s = %d, want 200", shopProcessID, code)
+	}
+	return response.ProcessID
+}
+
+// pay simula el pago aprobado de la compra en el checkout
+func (s *testServer) pay(processID string) {
+	s.t.Helper()
+	if code := s.do(http.MethodPost, "/emulator/bancard/"+processID+"?result=success", nil, nil); code != http.StatusOK {
+		s.t.Fatalf("pay %s = %d, want 200", processID, code)
+	}
+}
+
+// transaction devuelve el estado almacenado de la compra
+func (s *testServer) transaction(processID string) *store.Transaction {
+	s.t.Helper()
+	tx, err := s.plugin.store.Get(GatewayName, processID)
+	if err != nil {
+		s.t.Fatalf("Get(%s): %v", processID, err)
+	}
+	return tx
+}
+
+// errorKey devuelve la clave del primer mensaje de una respuesta de error
+func errorKey(response BancardErrorResponse) string {
+	if len(response.Messages) == 0 {
+		return ""
+	}
+	return response.Messages[0].Key
+}
+
+func TestShopProcessIDIsScopedToMerchant(t *testing.T) {
+	s := newTestServer(t)
+
+	s.singleBuy(merchantA, "1001", "10000.00")
+	s.singleBuy(merchantB, "1001", "20000.00")
+
+	var response BancardErrorResponse
+	code := s.do(http.MethodPost, "/vpos/api/0.3/single_buy", operation(merchantA, gin.H{
+		"token":           singleBuyToken(merchantA.PrivateKey, "1001", "10000.00", CurrencyPYG),
+		"shop_process_id": "1001",
+		"amount":          "10000.00",
+	}), &response)
+	if code != http.StatusBadRequest || errorKey(response) != ErrKeyDuplicatedProcessID {
+		t.Errorf("repeated single_buy = %d %s, want 400 %s", code, errorKey(response), ErrKeyDuplicatedProcessID)
+	}
+}
+
+func TestPurchaseOfAnotherMerchantIsNotFound(t *testing.T) {
+	s := newTestServer(t)
+
+	processID := s.singleBuy(merchantA, "2002", "10000.00")
+	s.pay(processID)
+
+	tests := []struct {
+		name string
+		path string
+		body gin.H
+	}{
+		{"single_buy/confirmations", "/vpos/api/0.3/single_buy/confirmations", operation(merchantB, gin.H{
+			"token": getConfirmationToken(merchantB.PrivateKey, "2002"), "shop_process_id": "2002",
+		})},
+		{"confirmation", "/vpos/api/0.3/confirmation", gin.H{
+			"public_key": merchantB.PublicKey, "shop_process_id": "2002", "token": getConfirmationToken(merchantB.PrivateKey, "2002"),
+		}},
+		{"single_buy/rollback", "/vpos/api/0.3/single_buy/rollback", operation(merchantB, gin.H{
+			"token": rollbackToken(merchantB.PrivateKey, "2002"), "shop_process_id": "2002",
+		})},
+		{"refund", "/vpos/api/0.3/refund", operation(merchantB, gin.H{
+			"token": refundToken(merchantB.PrivateKey, "2002", ""), "shop_process_id": "2002",
+		})},
+		{"preauthorizations/confirm", "/vpos/api/0.3/preauthorizations/confirm", operation(merchantB, gin.H{
+			"token": preauthorizationConfirmToken(merchantB.PrivateKey, "2002"), "shop_process_id": "2002",
+		})},
+		{"preauthorizations/rollback", "/vpos/api/0.3/preauthorizations/rollback", operation(merchantB, gin.H{
+			"token": preauthorizationRollbackToken(merchantB.PrivateKey, "2002"), "shop_process_id": "2002",
+		})},
+	}
+
+	for _, tt := range tests {
+		var response BancardErrorResponse
+		code := s.do(http.MethodPost, tt.path, tt.body, &response)
+		if code != http.StatusNotFound || errorKey(response) != ErrKeyPaymentNotFound {
+			t.Errorf("%s by another merchant = %d %s, want 404 %s", tt.name, code, errorKey(response), ErrKeyPaymentNotFound)
+		}
+	}
+
+	if tx := s.transaction(processID); tx.Status != store.StatusPaid || refundedAmount(tx) != 0 {
+		t.Errorf("purchase is %s with %.2f refunded, want untouched paid purchase", tx.Status, refundedAmount(tx))
+	}
+}
+
+func TestLegacyConfirmationFindsPurchaseOfTokenSigner(t *testing.T) {
+	s := newTestServer(t)
+
+	s.singleBuy(merchantA, "3003", "10000.00")
+	processID := s.singleBuy(merchantB, "3003", "20000.00")
+	s.pay(processID)
+
+	// Sin public_key la compra es la del comercio que firmó el token
+	var response BancardConfirmationResponse
+	code := s.do(http.MethodPost, "/vpos/api/0.3/confirmation", gin.H{
+		"shop_process_id": "3003",
+		"token":           getConfirmationToken(merchantB.PrivateKey, "3003"),
+	}, &response)
+	if code != http.StatusOK || response.Amount != "20000.00" {
+		t.Errorf("legacy confirmation = %d amount %s, want 200 amount 20000.00", code, response.Amount)
+	}
+}
+
+func TestSingleBuyRejectsInvalidAmounts(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, amount := range []string{"NaN", "Inf", "-Infinity", "0", "0.00", "-100.00", "abc"} {
+		var response BancardErrorResponse
+		code := s.do(http.MethodPost, "/vpos/api/0.3/single_buy", operation(merchantA, gin.H{
+			"token":           singleBuyToken(merchantA.PrivateKey, "4004", amount, CurrencyPYG),
+			"shop_process_id": "4004",
+			"amount":          amount,
+		}), &response)
+		if code != http.StatusBadRequest || errorKey(response) != ErrKeyInvalidAmount {
+			t.Errorf("single_buy amount %q = %d %s, want 400 %s", amount, code, errorKey(response), ErrKeyInvalidAmount)
+		}
+	}
+
+	if transactions := s.plugin.store.List(GatewayName); len(transactions) != 0 {
+		t.Errorf("invalid amounts created %d transactions", len(transactions))
+	}
+}
@@ -0,0 +1,199 @@
+package bancard
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"payment-emulator/internal/plugins"
+	"payment-emulator/internal/store"
+	"strconv"
+	"strings"
+)
+
+// cardProfile identifica la tarjeta usada en un pago para aplicar cuotas y promociones.
+// Un perfil nil representa un pago simulado sin datos de tarjeta.
+type cardProfile struct {
+	Brand  string
+	Issuer string
+}
+
+// BancardInstallmentOption representa una opción de cuotas ofrecida en el checkout
+type BancardInstallmentOption struct {
+	Brand             string  `json:"brand"`
+	Issuer            string  `json:"issuer"`
+	Installments      int     `json:"installments"`
+	InterestRate      float64 `json:"interest_rate"`
+	InstallmentAmount string  `json:"installment_amount"`
+	Label             string  `json:"label"`
+}
+
+// installmentPlans devuelve los planes de cuotas configurados
+func (p *BancardPlugin) installmentPlans() []plugins.InstallmentPlan {
+	return p.config.InstallmentPlans
+}
+
+// findPromotion busca un código de promoción configurado
+func (p *BancardPlugin) findPromotion(code string) (*plugins.Promotion, bool) {
+	return p.config.FindPromotion(code)
+}
+
+// cardRestriction es un plan de cuotas o una promoción que puede limitarse a una marca o emisor
+type cardRestriction interface {
+	Matches(brand, issuer string) bool
+}
+
+// matches indica si la tarjeta participa de un plan o promoción. Sin tarjeta (pago simulado por
+// API o con Zimple) solo aplican los planes y promociones no restringidos a una marca o emisor.
+func (card *cardProfile) matches(target cardRestriction) bool {
+	if card == nil {
+		return target.Matches("", "")
+	}
+	return target.Matches(card.Brand, card.Issuer)
+}
+
+// installmentPlan valida que la tarjeta admita pagar el monto en la cantidad de cuotas indicada.
+// Un pago en una cuota no requiere plan.
+func (p *BancardPlugin) installmentPlan(card *cardProfile, installments int, amount float64) (*plugins.InstallmentPlan, *BancardError) {
+	return p.findInstallmentPlan(installments, amount, func(plan *plugins.InstallmentPlan) bool {
+		return card.matches(plan)
+	})
+}
+
+// offeredInstallmentPlan valida que algún plan ofrezca la cantidad de cuotas para el monto.
+// Se usa en single_buy, cuando la tarjeta todavía no se eligió en el checkout.
+func (p *BancardPlugin) offeredInstallmentPlan(installments int, amount float64) (*plugins.InstallmentPlan, *BancardError) {
+	return p.findInstallmentPlan(installments, amount, func(plan *plugins.InstallmentPlan) bool {
+		return true
+	})
+}
+
+func (p *BancardPlugin) findInstallmentPlan(installments int, amount float64, matches func(plan *plugins.InstallmentPlan) bool) (*plugins.InstallmentPlan, *BancardError) {
+	if installments <= 1 {
+		return nil, nil
+	}
+
+	var minAmount float64
+	plans := p.installmentPlans()
+	for i := range plans {
+		plan := &plans[i]
+		if !plan.Allows(installments) || !matches(plan) {
+			continue
+		}
+		if amount >= plan.MinAmount {
+			return plan, nil
+		}
+		if minAmount == 0 || plan.MinAmount < minAmount {
+			minAmount = plan.MinAmount
+		}
+	}
+
+	if minAmount > 0 {
+		return nil, newBancardError(http.StatusBadRequest, ErrKeyInvalidInstallments,
+			fmt.Sprintf("El monto mínimo para pagar en %d cuotas es %s", installments, store.FormatAmount(minAmount)))
+	}
+	return nil, newBancardError(http.StatusBadRequest, ErrKeyInvalidInstallments,
+		fmt.Sprintf("La tarjeta no admite pago en %d cuotas", installments))
+}
+
+// installmentOptions devuelve las opciones de cuotas disponibles para el monto de la compra
+func (p *BancardPlugin) installmentOptions(amount float64, currency string) []BancardInstallmentOption {
+	options := make([]BancardInstallmentOption, 0)
+	for _, plan := range p.installmentPlans() {
+		if amount < plan.MinAmount {
+			continue
+		}
+		for _, installments := range plan.Installments {
+			installmentAmount := roundAmount(financedAmount(amount, plan.InterestRate)/float64(installments), currency)
+			label := fmt.Sprintf("%d cuotas de %s", installments, store.FormatAmount(installmentAmount))
+			if plan.InterestRate == 0 {
+				label += " sin intereses"
+			} else {
+				label += fmt.Sprintf(" (%s%% de interés)", strconv.FormatFloat(plan.InterestRate, 'f', -1, 64))
+			}
+			if plan.Issuer != "" {
+				label += " - solo " + plan.Issuer
+			}
+
+			options = append(options, BancardInstallmentOption{
+				Brand:             plan.Brand,
+				Issuer:            plan.Issuer,
+				Installments:      installments,
+				InterestRate:      plan.InterestRate,
+				InstallmentAmount: store.FormatAmount(installmentAmount),
+				Label:             label,
+			})
+		}
+	}
+	return options
+}
+
+// setInstallmentMeta registra en la transacción las cuotas elegidas y el monto de cada cuota
+func setInstallmentMeta(tx *store.Transaction, installments int, plan *plugins.InstallmentPlan) {
+	if installments < 1 {
+		installments = 1
+	}
+
+	var interestRate float64
+	if plan != nil {
+		interestRate = plan.InterestRate
+	}
+
+	tx.SetMeta(MetaNumberOfPayments, strconv.Itoa(installments))
+	tx.SetMeta(MetaInterestRate, strconv.FormatFloat(interestRate, 'f', -1, 64))
+	tx.SetMeta(MetaInstallmentAmount, store.FormatAmount(roundAmount(financedAmount(tx.Amount, interestRate)/float64(installments), tx.Currency)))
+}
+
+// numberOfPayments devuelve las cuotas de un pago aprobado (0 si todavía no fue aprobado)
+func numberOfPayments(tx *store.Transaction) int {
+	if tx.Meta(MetaInstallmentAmount) == "" {
+		return 0
+	}
+	installments, _ := strconv.Atoi(tx.Meta(MetaNumberOfPayments))
+	return installments
+}
+
+// applyPromotion aplica el descuento de la promoción de la transacción si la tarjeta participa.
+// El monto original queda en metadata y el monto de la transacción pasa a ser el cobrado.
+func (p *BancardPlugin) applyPromotion(tx *store.Transaction, card *cardProfile) bool {
+	code := tx.Meta(MetaPromotionCode)
+	if code == "" {
+		return false
+	}
+
+	promotion, ok := p.findPromotion(code)
+	if !ok || tx.Amount < promotion.MinAmount || !card.matches(promotion) {
+		return false
+	}
+
+	discounted := roundAmount(tx.Amount*(100-promotion.DiscountPercent)/100, tx.Currency)
+	tx.SetMeta(MetaOriginalAmount, store.FormatAmount(tx.Amount))
+	tx.SetMeta(MetaDiscountAmount, store.FormatAmount(tx.Amount-discounted))
+	tx.Amount = discounted
+	return true
+}
+
+// promotionCode obtiene el código de promoción de la operación (promotion_code o additional_data)
+func promotionCode(operation BancardOperation) string {
+	if operation.PromotionCode != "" {
+		return strings.TrimSpace(operation.PromotionCode)
+	}
+	if data, ok := operation.AdditionalData.(map[string]interface{}); ok {
+		if code, ok := data["promotion_code"].(string); ok {
+			return strings.TrimSpace(code)
+		}
+	}
+	return ""
+}
+
+// financedAmount devuelve el monto total a pagar en cuotas con el interés del plan
+func financedAmount(amount, interestRate float64) float64 {
+	return amount * (100 + interestRate) / 100
+}
+
+// roundAmount redondea un monto a la precisión de la moneda (el guaraní no tiene decimales)
+func roundAmount(amount float64, currency string) float64 {
+	if currency == CurrencyPYG {
+		return math.Round(amount)
+	}
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,44 @@
+package bancard
+
+import (
+	"net/http"
+	"payment-emulator/internal/store"
+)
+
+// bancardLifecycle define las transiciones permitidas para transacciones de Bancard.
+// Un pago rechazado o cancelado en el iframe no puede reintentarse con el mismo process_id.
+// Las preautorizaciones aprobadas quedan authorized hasta que el comercio las captura o libera.
+var bancardLifecycle = store.Lifecycle{
+	Transitions: map[store.Status][]store.Status{
+		store.StatusCreated:    {store.StatusPending, store.StatusPaid, store.StatusAuthorized, store.StatusFailed, store.StatusCancelled, store.StatusExpired},
+		store.StatusPending:    {store.StatusPaid, store.StatusAuthorized, store.StatusFailed, store.StatusCancelled, store.StatusExpired},
+		store.StatusAuthorized: {store.StatusPaid, store.StatusCancelled, store.StatusExpired},
+		store.StatusPaid:       {store.StatusRefunded, store.StatusReversed},
+	},
+	Reject: rejectTransition,
+}
+
+// rejectTransition traduce una transición inválida al error equivalente de Bancard
+func rejectTransition(err *store.TransitionError) error {
+	return alreadyProcessedError(err.From)
+}
+
+// alreadyProcessedError devuelve el error de Bancard para una transacción que ya salió del flujo de pago
+func alreadyProcessedError(from store.Status) *BancardError {
+	switch from {
+	case store.StatusPaid, store.StatusRefunded:
+		return newBancardError(http.StatusBadRequest, ErrKeyAlreadyConfirmed, "La transacción ya fue confirmada")
+	case store.StatusAuthorized:
+		return newBancardError(http.StatusBadRequest, ErrKeyInvalidOperation, "La preautorización está pendiente de confirmación")
+	case store.StatusCancelled, store.StatusReversed:
+		return newBancardError(http.StatusBadRequest, ErrKeyAlreadyRollbacked, "La transacción ya fue cancelada")
+	case store.StatusExpired:
+		return newBancardError(http.StatusBadRequest, ErrKeyInvalidOperation, "La transacción expiró")
+	default:
+		return newBancardError(http.StatusBadRequest, ErrKeyInvalidOperation, "La transacción ya fue procesada (estado: "+string(from)+")")
+	}
+}
+
+func init() {
+	store.GetGlobalStore().RegisterLifecycle(GatewayName, bancardLifecycle)
+}
@@ -0,0 +1,60 @@
+package bancard
+
+import (
+	"payment-emulator/internal/store"
+	"testing"
+)
+
+func TestBancardLifecycleAllows(t *testing.T) {
+	tests := []struct {
+		from, to store.Status
+		want     bool
+	}{
+		{store.StatusCreated, store.StatusPending, true},
+		{store.StatusCreated, store.StatusAuthorized, true},
+		{store.StatusCreated, store.StatusRefunded, false},
+		{store.StatusPending, store.StatusPaid, true},
+		{store.StatusPending, store.StatusPending, false},
+		{store.StatusAuthorized, store.StatusPaid, true},
+		{store.StatusAuthorized, store.StatusCancelled, true},
+		{store.StatusAuthorized, store.StatusRefunded, false},
+		{store.StatusPaid, store.StatusRefunded, true},
+		{store.StatusPaid, store.StatusReversed, true},
+		{store.StatusPaid, store.StatusCancelled, false},
+		{store.StatusFailed, store.StatusPaid, false},
+		{store.StatusCancelled, store.StatusPaid, false},
+		{store.StatusReversed, store.StatusPaid, false},
+	}
+
+	for _, tt := range tests {
+		if got := bancardLifecycle.Allows(tt.from, tt.to); got != tt.want {
+			t.Errorf("Allows(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestBancardLifecycleReject(t *testing.T) {
+	tests := []struct {
+		from store.Status
+		key  string
+	}{
+		{store.StatusPaid, ErrKeyAlreadyConfirmed},
+		{store.StatusRefunded, ErrKeyAlreadyConfirmed},
+		{store.StatusAuthorized, ErrKeyInvalidOperation},
+		{store.StatusCancelled, ErrKeyAlreadyRollbacked},
+		{store.StatusReversed, ErrKeyAlreadyRollbacked},
+		{store.StatusExpired, ErrKeyInvalidOperation},
+		{store.StatusFailed, ErrKeyInvalidOperation},
+	}
+
+	for _, tt := range tests {
+		err := rejectTransition(&store.TransitionError{Gateway: GatewayName, ID: "1", From: tt.from, To: store.StatusPaid})
+		bancardError, ok := err.(*BancardError)
+		if !ok {
+			t.Fatalf("rejectTransition(%s) = %T, want *BancardError", tt.from, err)
+		}
+		if bancardError.Key != tt.key {
+			t.Errorf("rejectTransition(%s) key = %s, want %s", tt.from, bancardError.Key, tt.key)
+		}
+	}
+}
@@ -1,5 +1,10 @@
 package bancard
 
+import (
+	"payment-emulator/internal/webhook"
+	"time"
+)
+
 // BancardOrderRequest representa una petición de pago de Bancard
 type BancardOrderRequest struct {
 	PublicKey string           `json:"public_key" binding:"required"`
@@ -10,12 +15,18 @@ type BancardOrderRequest struct {
 
 // BancardOperation representa los datos de la operación
 type BancardOperation struct {
-	Token            string                 `json:"token" binding:"required"`
-	ShopProcessID    string                 `json:"shop_process_id" binding:"required"`
-	Amount           string                 `json:"amount" binding:"required"`
-	Currency         string                 `json:"currency,omitempty"`
-	NumberOfPayments int                    `json:"number_of_payments,omitempty"`
-	AdditionalData   map[string]interface{} `json:"additional_data,omitempty"`
+	Token            string      `json:"token" binding:"required"`
+	ShopProcessID    string      `json:"shop_process_id" binding:"required"`
+	Amount           string      `json:"amount" binding:"required"`
+	Currency         string      `json:"currency,omitempty"`
+	Description      string      `json:"description,omitempty"`
+	ReturnURL        string      `json:"return_url,omitempty"`
+	CancelURL        string      `json:"cancel_url,omitempty"`
+	NumberOfPayments int         `json:"number_of_payments,omitempty"` // Cuotas preseleccionadas en el checkout
+	PromotionCode    string      `json:"promotion_code,omitempty"`
+	Preauthorization string      `json:"preauthorization,omitempty"` // "S" retiene los fondos hasta preauthorizations/confirm
+	Zimple           string      `json:"zimple,omitempty"`           // "S" paga con la billetera Zimple
+	AdditionalData   interface{} `json:"additional_data,omitempty"`  // Objeto o, con zimple, el número de celular
 }
 
 // BancardOrderResponse representa la respuesta de creación de orden
@@ -27,29 +38,64 @@ type BancardOrderResponse struct {
 }
 
 // BancardConfirmationRequest representa una petición de confirmación
+// El token puede enviarse en token o en operation.token; basta con uno de los dos.
 type BancardConfirmationRequest struct {
+	PublicKey     string                  `json:"public_key,omitempty"`
 	ShopProcessID string                  `json:"shop_process_id" binding:"required"`
-	Token         string                  `json:"token" binding:"required"`
-	Operation     BancardConfirmOperation `json:"operation" binding:"required"`
+	Token         string                  `json:"token,omitempty"`
+	Operation     BancardConfirmOperation `json:"operation"`
 }
 
 // BancardConfirmOperation representa los datos de confirmación
 type BancardConfirmOperation struct {
-	Token string `json:"token" binding:"required"`
+	Token string `json:"token,omitempty"`
 }
 
 // BancardConfirmationResponse representa la respuesta de confirmación
 type BancardConfirmationResponse struct {
-	Status              string              `json:"status"`
-	Message             string              `json:"message"`
-	TransactionID       string              `json:"transaction_id,omitempty"`
-	AuthorizationNumber string              `json:"authorization_number,omitempty"`
-	TicketNumber        string              `json:"ticket_number,omitempty"`
-	ResponseCode        string              `json:"response_code,omitempty"`
-	ResponseDescription string              `json:"response_description,omitempty"`
-	Amount              string              `json:"amount,omitempty"`
-	Currency            string              `json:"currency,omitempty"`
-	Security            BancardSecurityInfo `json:"security,omitempty"`
+	Status                      string              `json:"status"`
+	Message                     string              `json:"message"`
+	TransactionID               string              `json:"transaction_id,omitempty"`
+	AuthorizationNumber         string              `json:"authorization_number,omitempty"`
+	TicketNumber                string              `json:"ticket_number,omitempty"`
+	ResponseCode                string              `json:"response_code,omitempty"`
+	ResponseDescription         string              `json:"response_description,omitempty"`
+	ExtendedResponseDescription string              `json:"extended_response_description,omitempty"`
+	NumberOfPayments            int                 `json:"number_of_payments,omitempty"`
+	Amount                      string              `json:"amount,omitempty"`
+	Currency                    string              `json:"currency,omitempty"`
+	Security                    BancardSecurityInfo `json:"security,omitempty"`
+}
+
+// BancardConfirmationCallback representa la confirmación que Bancard envía al comercio
+type BancardConfirmationCallback struct {
+	Operation BancardCallbackOperation `json:"operation"`
+}
+
+// BancardCallbackOperation representa los datos de la operación confirmada
+type BancardCallbackOperation struct {
+	Token                       string                     `json:"token"`
+	ShopProcessID               string                     `json:"shop_process_id"`
+	Response                    string                     `json:"response"`
+	ResponseDetails             string                     `json:"response_details"`
+	Amount                      string                     `json:"amount"`
+	Currency                    string                     `json:"currency"`
+	AuthorizationNumber         string                     `json:"authorization_number"`
+	TicketNumber                string                     `json:"ticket_number"`
+	ResponseCode                string                     `json:"response_code"`
+	ResponseDescription         string                     `json:"response_description"`
+	ExtendedResponseDescription interface{}                `json:"extended_response_description"`
+	NumberOfPayments            int                        `json:"number_of_payments,omitempty"`
+	SecurityInformation         BancardSecurityInformation `json:"security_information"`
+}
+
+// BancardSecurityInformation representa la información de seguridad de la confirmación
+type BancardSecurityInformation struct {
+	CustomerIP  string `json:"customer_ip"`
+	CardSource  string `json:"card_source"`
+	CardCountry string `json:"card_country"`
+	Version     string `json:"version"`
+	RiskIndex   int    `json:"risk_index"`
 }
 
 // BancardSecurityInfo representa información de seguridad
@@ -83,6 +129,227 @@ type BancardRiskAnalysis struct {
 	Recommendation string `json:"recommendation"`
 }
 
+// BancardGetConfirmationRequest representa la consulta de confirmación de un pago
+type BancardGetConfirmationRequest struct {
+	PublicKey string                          `json:"public_key" binding:"required"`
+	Operation BancardGetConfirmationOperation `json:"operation" binding:"required"`
+}
+
+// BancardGetConfirmationOperation representa la operación de consulta de confirmación
+type BancardGetConfirmationOperation struct {
+	Token         string `json:"token" binding:"required"`
+	ShopProcessID string `json:"shop_process_id" binding:"required"`
+}
+
+// BancardGetConfirmationResponse representa la respuesta de la consulta de confirmación
+type BancardGetConfirmationResponse struct {
+	Status       string                   `json:"status"`
+	Confirmation BancardCallbackOperation `json:"confirmation"`
+}
+
+// BancardRollbackRequest representa la petición de reversa de un pago
+type BancardRollbackRequest struct {
+	PublicKey string                   `json:"public_key" binding:"required"`
+	Operation BancardRollbackOperation `json:"operation" binding:"required"`
+}
+
+// BancardRollbackOperation representa la operación de reversa
+type BancardRollbackOperation struct {
+	Token         string `json:"token" binding:"required"`
+	ShopProcessID string `json:"shop_process_id" binding:"required"`
+}
+
+// BancardRollbackResponse representa la respuesta de una reversa
+type BancardRollbackResponse struct {
+	Status   string           `json:"status"`
+	Messages []BancardMessage `json:"messages"`
+}
+
+// BancardRefundRequest representa la petición de reembolso de un pago
+type BancardRefundRequest struct {
+	PublicKey string                 `json:"public_key" binding:"required"`
+	Operation BancardRefundOperation `json:"operation" binding:"required"`
+}
+
+// BancardRefundOperation representa la operación de reembolso (sin amount = reembolso total)
+type BancardRefundOperation struct {
+	Token         string `json:"token" binding:"required"`
+	ShopProcessID string `json:"shop_process_id" binding:"required"`
+	Amount        string `json:"amount,omitempty"`
+}
+
+// BancardRefundResponse representa la respuesta de un reembolso
+type BancardRefundResponse struct {
+	Status   string               `json:"status"`
+	Messages []BancardMessage     `json:"messages"`
+	Refund   BancardRefundDetails `json:"refund"`
+}
+
+// BancardRefundDetails representa el detalle de un reembolso y el saldo restante
+type BancardRefundDetails struct {
+	ShopProcessID    string `json:"shop_process_id"`
+	Amount           string `json:"amount"`
+	Currency         string `json:"currency"`
+	RefundedAmount   string `json:"refunded_amount"`
+	RefundableAmount string `json:"refundable_amount"`
+}
+
+// BancardPreauthorizationRequest representa la confirmación o liberación de una preautorización
+type BancardPreauthorizationRequest struct {
+	PublicKey string                           `json:"public_key" binding:"required"`
+	Operation BancardPreauthorizationOperation `json:"operation" binding:"required"`
+}
+
+// BancardPreauthorizationOperation representa la operación sobre una preautorización
+// (amount solo aplica a la confirmación; sin monto se captura el total retenido)
+type BancardPreauthorizationOperation struct {
+	Token         string `json:"token" binding:"required"`
+	ShopProcessID string `json:"shop_process_id" binding:"required"`
+	Amount        string `json:"amount,omitempty"`
+}
+
+// BancardNewCardRequest representa la petición de catastro de una nueva tarjeta
+type BancardNewCardRequest struct {
+	PublicKey string                  `json:"public_key" binding:"required"`
+	Operation BancardNewCardOperation `json:"operation" binding:"required"`
+}
+
+// BancardNewCardOperation representa la operación de catastro de tarjeta
+type BancardNewCardOperation struct {
+	Token         string `json:"token" binding:"required"`
+	CardID        string `json:"card_id" binding:"required"`
+	UserID        string `json:"user_id" binding:"required"`
+	UserCellPhone string `json:"user_cell_phone,omitempty"`
+	UserMail      string `json:"user_mail,omitempty"`
+	ReturnURL     string `json:"return_url,omitempty"`
+}
+
+// BancardUserCardsRequest representa la petición de listado o eliminación de tarjetas de un usuario
+type BancardUserCardsRequest struct {
+	PublicKey string                   `json:"public_key" binding:"required"`
+	Operation BancardUserCardOperation `json:"operation" binding:"required"`
+}
+
+// BancardUserCardOperation representa la operación sobre las tarjetas de un usuario
+// (alias_token solo es obligatorio al eliminar)
+type BancardUserCardOperation struct {
+	Token      string `json:"token" binding:"required"`
+	AliasToken string `json:"alias_token,omitempty"`
+}
+
+// BancardUserCardsResponse representa el listado de tarjetas de un usuario
+type BancardUserCardsResponse struct {
+	Status string        `json:"status"`
+	Cards  []BancardCard `json:"cards"`
+}
+
+// BancardCard representa una tarjeta catastrada tal como la devuelve Bancard
+type BancardCard struct {
+	AliasToken       string `json:"alias_token"`
+	CardMaskedNumber string `json:"card_masked_number"`
+	ExpirationDate   string `json:"expiration_date"`
+	CardBrand        string `json:"card_brand"`
+	CardID           string `json:"card_id"`
+	CardType         string `json:"card_type"`
+}
+
+// BancardChargeRequest representa un cobro con una tarjeta catastrada
+type BancardChargeRequest struct {
+	PublicKey string                 `json:"public_key" binding:"required"`
+	Operation BancardChargeOperation `json:"operation" binding:"required"`
+}
+
+// BancardChargeOperation representa la operación de cobro con alias_token
+type BancardChargeOperation struct {
+	Token            string                 `json:"token" binding:"required"`
+	ShopProcessID    string                 `json:"shop_process_id" binding:"required"`
+	Amount           string                 `json:"amount" binding:"required"`
+	Currency         string                 `json:"currency,omitempty"`
+	NumberOfPayments int                    `json:"number_of_payments,omitempty"`
+	Description      string                 `json:"description,omitempty"`
+	AliasToken       string                 `json:"alias_token" binding:"required"`
+	AdditionalData   map[string]interface{} `json:"additional_data,omitempty"`
+}
+
+// BancardCardRegistration representa los datos cargados en el iframe de catastro
+type BancardCardRegistration struct {
+	Result         string `json:"result"`
+	CardNumber     string `json:"card_number"`
+	ExpirationDate string `json:"expiration_date"`
+	Cvv            string `json:"cvv"`
+	CardType       string `json:"card_type"`
+}
+
+// BancardCardData representa los datos de tarjeta cargados en el iframe de pago
+type BancardCardData struct {
+	CardNumber       string `json:"card_number"`
+	ExpirationDate   string `json:"expiration_date"` // MM/AA
+	Cvv              string `json:"cvv"`
+	Cardholder       string `json:"cardholder"`
+	Document         string `json:"document"`
+	NumberOfPayments int    `json:"number_of_payments"`
+	Phone            string `json:"phone"` // Zimple: celular de la billetera
+	Pin              string `json:"pin"`   // Zimple: PIN de confirmación
+}
+
+// BancardQRRequest representa la petición de generación de un QR (generate-qr-express)
+type BancardQRRequest struct {
+	Amount      float64 `json:"amount" binding:"required"`
+	Description string  `json:"description,omitempty"`
+}
+
+// BancardQRResponse representa la respuesta de generación de un QR
+type BancardQRResponse struct {
+	Status           string            `json:"status"`
+	QRExpress        BancardQRExpress  `json:"qr_express"`
+	SupportedClients []BancardQRClient `json:"supported_clients"`
+}
+
+// BancardQRExpress representa un QR generado para un punto de venta
+type BancardQRExpress struct {
+	Amount      float64 `json:"amount"`
+	HookAlias   string  `json:"hook_alias"`
+	Description string  `json:"description"`
+	URL         string  `json:"url"`     // Página con el QR
+	QRData      string  `json:"qr_data"` // Contenido codificado en el QR
+	CreatedAt   string  `json:"created_at"`
+}
+
+// BancardQRClient representa una aplicación que puede pagar el QR
+type BancardQRClient struct {
+	Name    string `json:"name"`
+	LogoURL string `json:"logo_url"`
+}
+
+// BancardQRPayment representa el estado de un pago QR
+type BancardQRPayment struct {
+	HookAlias           string  `json:"hook_alias"`
+	Status              string  `json:"status"` // pending, confirmed, rejected, reverted, cancelled
+	Amount              float64 `json:"amount"`
+	Currency            string  `json:"currency"`
+	Description         string  `json:"description"`
+	CommerceCode        string  `json:"commerce_code"`
+	BranchCode          string  `json:"branch_code"`
+	ResponseCode        string  `json:"response_code,omitempty"`
+	ResponseDescription string  `json:"response_description,omitempty"`
+	AuthorizationNumber string  `json:"authorization_number,omitempty"`
+	TicketNumber        string  `json:"ticket_number,omitempty"`
+	PaymentMethod       string  `json:"payment_method,omitempty"`
+	CreatedAt           string  `json:"created_at"`
+	UpdatedAt           string  `json:"updated_at"`
+}
+
+// BancardQRPaymentResponse representa la respuesta de consulta de un pago QR
+type BancardQRPaymentResponse struct {
+	Status  string           `json:"status"`
+	Payment BancardQRPayment `json:"payment"`
+}
+
+// BancardQRNotification representa la notificación de pago QR enviada al comercio
+type BancardQRNotification struct {
+	Payment BancardQRPayment `json:"payment"`
+}
+
 // BancardCheckoutData representa los datos para el checkout
 type BancardCheckoutData struct {
 	ProcessID     string              `json:"process_id"`
@@ -103,15 +370,19 @@ type BancardOrderDetails struct {
 
 // BancardSimulationResult representa el resultado de una simulación
 type BancardSimulationResult struct {
-	Status        string `json:"status"`
-	ProcessID     string `json:"process_id"`
-	Message       string `json:"message"`
-	RedirectURL   string `json:"redirect_url,omitempty"`
-	TransactionID string `json:"transaction_id,omitempty"`
+	Status               string            `json:"status"`
+	ProcessID            string            `json:"process_id"`
+	Message              string            `json:"message"`
+	RedirectURL          string            `json:"redirect_url,omitempty"`
+	TransactionID        string            `json:"transaction_id,omitempty"`
+	ConfirmationDelivery *webhook.Delivery `json:"confirmation_delivery,omitempty"`
 }
 
 // Constantes para Bancard
 const (
+	// Nombre del gateway en el store de transacciones
+	GatewayName = "bancard"
+
 	// Estados de transacción
 	StatusSuccess   = "success"
 	StatusError     = "error"
@@ -130,8 +401,76 @@ const (
 	BrandVisa       = "VISA"
 	BrandMastercard = "MASTERCARD"
 	BrandAmex       = "AMEX"
+	BrandZimple     = "ZIMPLE"
 
 	// URLs por defecto
 	DefaultReturnURL = "/bancard/return"
 	DefaultCancelURL = "/bancard/cancel"
+
+	// Claves de metadata en el store
+	MetaTransactionID               = "transaction_id"
+	MetaAuthorizationNumber         = "authorization_number"
+	MetaTicketNumber                = "ticket_number"
+	MetaResponseCode                = "response_code"
+	MetaResponseDescription         = "response_description"
+	MetaExtendedResponseDescription = "extended_response_description"
+	MetaPublicKey                   = "public_key"
+	MetaCustomerIP                  = "customer_ip"
+	MetaRefundedAmount              = "refunded_amount"
+	MetaAliasToken                  = "alias_token"
+	MetaPreauthorization            = "preauthorization"
+	MetaHeldAmount                  = "held_amount"
+	MetaCardMaskedNumber            = "card_masked_number"
+	MetaUserCellPhone               = "user_cell_phone"
+	MetaUserMail                    = "user_mail"
+	MetaTestCard                    = "test_card"
+	MetaCardBrand                   = "card_brand"
+	MetaCardIssuer                  = "card_issuer"
+	MetaNumberOfPayments            = "number_of_payments"
+	MetaInterestRate                = "interest_rate"
+	MetaInstallmentAmount           = "installment_amount"
+	MetaPromotionCode               = "promotion_code"
+	MetaOriginalAmount              = "original_amount"
+	MetaDiscountAmount              = "discount_amount"
+	MetaZimple                      = "zimple"
+	MetaZimplePhone                 = "zimple_phone"
+	MetaPaymentMode                 = "payment_mode"
+	MetaCommerceCode                = "commerce_code"
+	MetaBranchCode                  = "branch_code"
+	MetaQRData                      = "qr_data"
+	MetaQRPaymentMethod             = "qr_payment_method"
+
+	// Estados del retorno del catastro de tarjetas
+	CardStatusSuccess = "add_new_card_success"
+	CardStatusFail    = "add_new_card_fail"
+
+	// Respuestas de la confirmación
+	ResponseApproved = "S"
+	ResponseDeclined = "N"
+
+	// Valor de operation.preauthorization que habilita la preautorización
+	PreauthorizationEnabled = "S"
+
+	// Valor de operation.zimple que habilita el pago con Zimple
+	ZimpleEnabled = "S"
+
+	// Niveles de los mensajes de respuesta
+	MessageLevelInfo = "info"
+
+	// Plazo en el que un pago confirmado todavía puede reversarse
+	RollbackWindow = 24 * time.Hour
+
+	// Modos de pago que no usan el iframe de tarjeta
+	PaymentModeQR = "qr"
+
+	// Estados de un pago QR
+	QRStatusPending   = "pending"
+	QRStatusConfirmed = "confirmed"
+	QRStatusRejected  = "rejected"
+	QRStatusReverted  = "reverted"
+	QRStatusCancelled = "cancelled"
+
+	// Eventos de webhook de Bancard
+	WebhookEventConfirmation = "bancard.confirmation"
+	WebhookEventQRPayment    = "bancard.qr_payment"
 )
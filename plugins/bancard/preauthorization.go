@@ -0,0 +1,140 @@
+package bancard
+
+import (
+	"net/http"
+	"payment-emulator/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handlePreauthorizationConfirm captura una preautorización total o parcialmente.
+// El monto capturado no puede superar el retenido y pasa a ser el monto de la transacción.
+func (p *BancardPlugin) handlePreauthorizationConfirm(c *gin.Context) {
+	var request BancardPreauthorizationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+
+	tx, err := p.findPurchase(request.PublicKey, request.Operation.ShopProcessID)
+	if err != nil {
+		respondError(c, paymentNotFoundError(err))
+		return
+	}
+
+	merchant, err := p.verifyToken(request.PublicKey, request.Operation.Token, func(privateKey string) string {
+		return preauthorizationConfirmToken(privateKey, request.Operation.ShopProcessID)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := checkPreauthorization(tx); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	amount := heldAmount(tx)
+	if request.Operation.Amount != "" {
+		amount, err = store.ParseAmount(request.Operation.Amount)
+		if err != nil || toCents(amount) == 0 {
+			respondError(c, newBancardError(http.StatusBadRequest, ErrKeyInvalidAmount, "Monto a confirmar inválido: "+request.Operation.Amount))
+			return
+		}
+	}
+
+	tx, err = p.store.Transition(GatewayName, tx.ID, store.StatusPaid, "Preautorización confirmada por "+store.FormatAmount(amount), func(tx *store.Transaction) error {
+		held := heldAmount(tx)
+		if toCents(amount) > toCents(held) {
+			return newBancardError(http.StatusBadRequest, ErrKeyInvalidAmount, "El monto supera el monto preautorizado ("+store.FormatAmount(held)+" "+tx.Currency+")")
+		}
+		tx.Amount = amount
+		return nil
+	})
+	if err != nil {
+		respondError(c, paymentNotFoundError(err))
+		return
+	}
+
+	privateKey := ""
+	if merchant != nil {
+		privateKey = merchant.PrivateKey
+	}
+
+	c.JSON(http.StatusOK, BancardGetConfirmationResponse{
+		Status:       StatusSuccess,
+		Confirmation: buildConfirmationOperation(tx, privateKey),
+	})
+}
+
+// handlePreauthorizationRollback libera los fondos retenidos de una preautorización
+func (p *BancardPlugin) handlePreauthorizationRollback(c *gin.Context) {
+	var request BancardPreauthorizationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+
+	tx, err := p.findPurchase(request.PublicKey, request.Operation.ShopProcessID)
+	if err != nil {
+		respondError(c, paymentNotFoundError(err))
+		return
+	}
+
+	_, err = p.verifyToken(request.PublicKey, request.Operation.Token, func(privateKey string) string {
+		return preauthorizationRollbackToken(privateKey, request.Operation.ShopProcessID)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := checkPreauthorization(tx); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	_, err = p.store.Transition(GatewayName, tx.ID, store.StatusCancelled, "Preautorización liberada por el comercio", nil)
+	if err != nil {
+		respondError(c, paymentNotFoundError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, BancardRollbackResponse{
+		Status: StatusSuccess,
+		Messages: []BancardMessage{
+			{Key: "PreauthorizationRollbackSuccessful", Level: MessageLevelInfo, Dsc: "Preautorización cancelada con éxito."},
+		},
+	})
+}
+
+// isPreauthorization indica si la transacción se creó con preauthorization "S"
+func isPreauthorization(tx *store.Transaction) bool {
+	return tx.Meta(MetaPreauthorization) == PreauthorizationEnabled
+}
+
+// checkPreauthorization valida que la transacción sea una preautorización con fondos retenidos
+func checkPreauthorization(tx *store.Transaction) error {
+	if !isPreauthorization(tx) {
+		return newBancardError(http.StatusBadRequest, ErrKeyInvalidOperation, "La transacción no es una preautorización")
+	}
+
+	switch tx.Status {
+	case store.StatusAuthorized:
+		return nil
+	case store.StatusCreated, store.StatusPending:
+		return newBancardError(http.StatusBadRequest, ErrKeyPaymentNotConfirmed, "La preautorización todavía no fue aprobada")
+	default:
+		return alreadyProcessedError(tx.Status)
+	}
+}
+
+// heldAmount devuelve el monto retenido por la preautorización
+func heldAmount(tx *store.Transaction) float64 {
+	held, err := store.ParseAmount(tx.Meta(MetaHeldAmount))
+	if err != nil {
+		return tx.Amount
+	}
+	return held
+}
@@ -0,0 +1,64 @@
+package bancard
+
+import (
+	"net/http"
+	"payment-emulator/internal/store"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// preauthorize crea y aprueba una preautorización de merchantA y devuelve su process_id
+func (s *testServer) preauthorize(shopProcessID, amount string) string {
+	s.t.Helper()
+	var response BancardOrderResponse
+	code := s.do(http.MethodPost, "/vpos/api/0.3/single_buy", operation(merchantA, gin.H{
+		"token":            singleBuyToken(merchantA.PrivateKey, shopProcessID, amount, CurrencyPYG),
+		"shop_process_id":  shopProcessID,
+		"amount":           amount,
+		"currency":         CurrencyPYG,
+		"preauthorization": PreauthorizationEnabled,
+	}), &response)
+	if code != http.StatusOK {
+		s.t.Fatalf("single_buy with preauthorization %s = %d, want 200", shopProcessID, code)
+	}
+	s.pay(response.ProcessID)
+	return response.ProcessID
+}
+
+// capture confirma la preautorización de merchantA por el monto indicado
+func (s *testServer) capture(shopProcessID, amount string, out interface{}) int {
+	s.t.Helper()
+	return s.do(http.MethodPost, "/vpos/api/0.3/preauthorizations/confirm", operation(merchantA, gin.H{
+		"token":           preauthorizationConfirmToken(merchantA.PrivateKey, shopProcessID),
+		"shop_process_id": shopProcessID,
+		"amount":          amount,
+	}), out)
+}
+
+func TestCaptureCannotExceedHeldAmount(t *testing.T) {
+	s := newTestServer(t)
+	processID := s.preauthorize("8008", "10000.00")
+
+	for _, amount := range []string{"10000.01", "20000.00", "0", "NaN"} {
+		var response BancardErrorResponse
+		if code := s.capture("8008", amount, &response); code != http.StatusBadRequest || errorKey(response) != ErrKeyInvalidAmount {
+			t.Errorf("capture of %s = %d %s, want 400 %s", amount, code, errorKey(response), ErrKeyInvalidAmount)
+		}
+	}
+	if tx := s.transaction(processID); tx.Status != store.StatusAuthorized || tx.Amount != 10000 {
+		t.Fatalf("rejected captures left the purchase %s for %.2f, want %s for 10000.00", tx.Status, tx.Amount, store.StatusAuthorized)
+	}
+
+	if code := s.capture("8008", "7500.00", nil); code != http.StatusOK {
+		t.Fatalf("partial capture = %d, want 200", code)
+	}
+	if tx := s.transaction(processID); tx.Status != store.StatusPaid || tx.Amount != 7500 {
+		t.Errorf("captured purchase is %s for %.2f, want %s for 7500.00", tx.Status, tx.Amount, store.StatusPaid)
+	}
+
+	var again BancardErrorResponse
+	if code := s.capture("8008", "2500.00", &again); code != http.StatusBadRequest {
+		t.Errorf("second capture = %d %s, want 400", code, errorKey(again))
+	}
+}
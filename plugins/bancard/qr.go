@@ -0,0 +1,378 @@
+package bancard
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"payment-emulator/internal/plugins"
+	"payment-emulator/internal/store"
+	"payment-emulator/internal/webhook"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// qrClient es una aplicación que puede pagar un QR de Bancard, con el logo que sirve el emulador
+type qrClient struct {
+	Name  string
+	Logo  string
+	Color string
+}
+
+// qrClients son las aplicaciones que pueden pagar un QR de Bancard
+var qrClients = []qrClient{
+	{Name: "Bancard Móvil", Logo: "bancard.svg", Color: "#0a3d7a"},
+	{Name: "Zimple", Logo: "zimple.svg", Color: "#e4002b"},
+	{Name: "Billetera Personal", Logo: "personal.svg", Color: "#00a6e2"},
+}
+
+// qrLogoSVG es un logo genérico con las iniciales de la aplicación
+const qrLogoSVG = `<svg xmlns="http://www.w3.org/2000/svg" width="96" height="96" viewBox="0 0 96 96">` +
+	`<rect width="96" height="96" rx="16" fill="%s"/>` +
+	`<text x="48" y="58" font-family="Arial, sans-serif" font-size="32" font-weight="bold" fill="#fff" text-anchor="middle">%s</text></svg>`
+
+// supportedQRClients arma la lista supported_clients con URLs absolutas a los logos
+func supportedQRClients(origin string) []BancardQRClient {
+	clients := make([]BancardQRClient, 0, len(qrClients))
+	for _, client := range qrClients {
+		clients = append(clients, BancardQRClient{
+			Name:    client.Name,
+			LogoURL: origin + "/bancard/qr-clients/" + client.Logo,
+		})
+	}
+	return clients
+}
+
+// handleGenerateQR genera un QR para cobrar en un punto de venta físico (generate-qr-express)
+func (p *BancardPlugin) handleGenerateQR(c *gin.Context) {
+	merchant, err := p.verifyBasicAuth(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var request BancardQRRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+	if request.Amount <= 0 {
+		respondError(c, newBancardError(http.StatusBadRequest, ErrKeyInvalidAmount, "amount debe ser mayor a cero"))
+		return
+	}
+
+	commerceCode := c.Param("commerce_code")
+	branchCode := c.Param("branch_code")
+	hookAlias := generateHookAlias()
+	qrData := fmt.Sprintf("bancardqr://pay?alias=%s&commerce=%s&branch=%s&amount=%s",
+		hookAlias, url.QueryEscape(commerceCode), url.QueryEscape(branchCode), store.FormatAmount(request.Amount))
+
+	tx := &store.Transaction{
+		Gateway:     GatewayName,
+		ID:          hookAlias,
+		Reference:   hookAlias,
+		Amount:      request.Amount,
+		Currency:    CurrencyPYG,
+		Description: request.Description,
+	}
+	if merchant != nil {
+		tx.SetMeta(MetaPublicKey, merchant.PublicKey)
+	}
+	tx.SetMeta(MetaPaymentMode, PaymentModeQR)
+	tx.SetMeta(MetaCommerceCode, commerceCode)
+	tx.SetMeta(MetaBranchCode, branchCode)
+	tx.SetMeta(MetaQRData, qrData)
+
+	tx, err = p.store.Create(tx)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, BancardQRResponse{
+		Status:           StatusSuccess,
+		QRExpress:        buildQRExpress(tx, requestOrigin(c)),
+		SupportedClients: supportedQRClients(requestOrigin(c)),
+	})
+}
+
+// handleGetQRPayment consulta el estado de un pago QR
+func (p *BancardPlugin) handleGetQRPayment(c *gin.Context) {
+	merchant, err := p.verifyBasicAuth(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	tx, err := p.findCommerceQR(c, merchant)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, BancardQRPaymentResponse{
+		Status:  StatusSuccess,
+		Payment: buildQRPayment(tx),
+	})
+}
+
+// handleRevertQRPayment revierte un pago QR confirmado o anula un QR todavía no pagado
+func (p *BancardPlugin) handleRevertQRPayment(c *gin.Context) {
+	merchant, err := p.verifyBasicAuth(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	tx, err := p.findCommerceQR(c, merchant)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	to := store.StatusCancelled
+	note := "QR anulado por el comercio"
+	if tx.Status == store.StatusPaid {
+		to = store.StatusReversed
+		note = "Pago QR revertido por el comercio"
+	}
+
+	tx, err = p.store.Transition(GatewayName, tx.ID, to, note, nil)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, BancardQRPaymentResponse{
+		Status:  StatusSuccess,
+		Payment: buildQRPayment(tx),
+	})
+}
+
+// handleQRPage muestra el QR con los botones para simular el escaneo desde una billetera
+func (p *BancardPlugin) handleQRPage(c *gin.Context) {
+	tx, err := p.findQR(c.Param("hook_alias"))
+	if err != nil {
+		c.HTML(http.StatusNotFound, "bancard_result.html", gin.H{
+			"result":  StatusError,
+			"message": "El QR no existe",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "bancard_qr.html", gin.H{
+		"qr":             buildQRExpress(tx, requestOrigin(c)),
+		"payment":        buildQRPayment(tx),
+		"clients":        qrClients,
+		"response_codes": declineCodes(),
+	})
+}
+
+// handleQRClientLogo sirve el logo de una aplicación de supported_clients
+func (p *BancardPlugin) handleQRClientLogo(c *gin.Context) {
+	for _, client := range qrClients {
+		if client.Logo != c.Param("logo") {
+			continue
+		}
+		initials := ""
+		for _, word := range strings.Fields(client.Name) {
+			initials += string([]rune(word)[0])
+		}
+		c.Data(http.StatusOK, "image/svg+xml", []byte(fmt.Sprintf(qrLogoSVG, client.Color, initials)))
+		return
+	}
+	c.Status(http.StatusNotFound)
+}
+
+// handleEmulatorQR devuelve el contenido del QR para que una prueba lo "escanee"
+func (p *BancardPlugin) handleEmulatorQR(c *gin.Context) {
+	tx, err := p.findQR(c.Param("hook_alias"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     StatusSuccess,
+		"qr_express": buildQRExpress(tx, requestOrigin(c)),
+		"payment":    buildQRPayment(tx),
+	})
+}
+
+// handleEmulatorQRScan simula que una billetera escanea el QR y paga (o es rechazada)
+func (p *BancardPlugin) handleEmulatorQRScan(c *gin.Context) {
+	hookAlias := c.Param("hook_alias")
+	result := c.Query("result")
+
+	tx, err := p.findQR(hookAlias)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var newStatus store.Status
+	responseCode := responseCodeFor(ResponseCodeApproved, "")
+	switch result {
+	case StatusSuccess:
+		newStatus = store.StatusPaid
+	case StatusError:
+		newStatus = store.StatusFailed
+		code := firstNonEmpty(c.Query("response_code"), DefaultDeclineCode)
+		decline, ok := findResponseCode(code)
+		if !ok || decline.Approved() {
+			respondError(c, newBancardError(http.StatusBadRequest, ErrKeyInvalidOperation, "Código de respuesta de rechazo inválido: "+code))
+			return
+		}
+		responseCode = decline
+	default:
+		respondError(c, newBancardError(http.StatusBadRequest, ErrKeyInvalidOperation, "Resultado de simulación inválido: "+result))
+		return
+	}
+
+	paymentMethod := firstNonEmpty(c.Query("client"), qrClients[0].Name)
+	customerIP := c.ClientIP()
+	tx, err = p.store.Transition(GatewayName, hookAlias, newStatus, "Escaneo de QR simulado ("+paymentMethod+")", func(tx *store.Transaction) error {
+		tx.SetMeta(MetaCustomerIP, customerIP)
+		tx.SetMeta(MetaQRPaymentMethod, paymentMethod)
+		if newStatus == store.StatusPaid {
+			setApprovalMeta(tx)
+			return nil
+		}
+		setResponseCodeMeta(tx, responseCode)
+		return nil
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":                result,
+		"payment":               buildQRPayment(tx),
+		"notification_delivery": p.sendQRNotification(tx),
+	})
+}
+
+// sendQRNotification notifica el resultado del pago QR a la URL de confirmación del comercio
+func (p *BancardPlugin) sendQRNotification(tx *store.Transaction) *webhook.Delivery {
+	merchant, ok := p.config.FindMerchant(tx.Meta(MetaPublicKey))
+	if !ok || merchant.ConfirmationURL == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(BancardQRNotification{Payment: buildQRPayment(tx)})
+	if err != nil {
+		return nil
+	}
+
+	return p.webhooks.Enqueue(webhook.Request{
+		Gateway:       GatewayName,
+		Event:         WebhookEventQRPayment,
+		TransactionID: tx.ID,
+		URL:           merchant.ConfirmationURL,
+		Body:          body,
+	})
+}
+
+// findQR busca un pago QR por hook_alias
+func (p *BancardPlugin) findQR(hookAlias string) (*store.Transaction, error) {
+	tx, err := p.store.Get(GatewayName, hookAlias)
+	if err != nil || tx.Meta(MetaPaymentMode) != PaymentModeQR {
+		return nil, qrNotFoundError(hookAlias)
+	}
+	return tx, nil
+}
+
+// findCommerceQR busca el pago QR de la ruta entre los generados por el comercio autenticado en
+// el commerce_code y branch_code de la ruta. El QR de otro comercio o sucursal no se encuentra.
+func (p *BancardPlugin) findCommerceQR(c *gin.Context, merchant *plugins.Merchant) (*store.Transaction, error) {
+	hookAlias := c.Param("hook_alias")
+	tx, err := p.findQR(hookAlias)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey := ""
+	if merchant != nil {
+		publicKey = merchant.PublicKey
+	}
+	if tx.Meta(MetaPublicKey) != publicKey ||
+		tx.Meta(MetaCommerceCode) != c.Param("commerce_code") ||
+		tx.Meta(MetaBranchCode) != c.Param("branch_code") {
+		return nil, qrNotFoundError(hookAlias)
+	}
+	return tx, nil
+}
+
+// qrNotFoundError devuelve el error de Bancard para un hook_alias desconocido
+func qrNotFoundError(hookAlias string) *BancardError {
+	return newBancardError(http.StatusNotFound, ErrKeyPaymentNotFound, "No existe un QR con hook_alias "+hookAlias)
+}
+
+// buildQRExpress arma los datos del QR generado
+func buildQRExpress(tx *store.Transaction, origin string) BancardQRExpress {
+	return BancardQRExpress{
+		Amount:      tx.Amount,
+		HookAlias:   tx.ID,
+		Description: tx.Description,
+		URL:         origin + "/bancard/qr/" + tx.ID,
+		QRData:      tx.Meta(MetaQRData),
+		CreatedAt:   tx.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// buildQRPayment arma el estado del pago QR a partir de la transacción almacenada
+func buildQRPayment(tx *store.Transaction) BancardQRPayment {
+	payment := BancardQRPayment{
+		HookAlias:     tx.ID,
+		Status:        qrStatus(tx.Status),
+		Amount:        tx.Amount,
+		Currency:      tx.Currency,
+		Description:   tx.Description,
+		CommerceCode:  tx.Meta(MetaCommerceCode),
+		BranchCode:    tx.Meta(MetaBranchCode),
+		PaymentMethod: tx.Meta(MetaQRPaymentMethod),
+		CreatedAt:     tx.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     tx.UpdatedAt.Format(time.RFC3339),
+	}
+	if tx.Status != store.StatusCreated {
+		payment.ResponseCode = tx.Meta(MetaResponseCode)
+		payment.ResponseDescription = tx.Meta(MetaResponseDescription)
+		payment.AuthorizationNumber = tx.Meta(MetaAuthorizationNumber)
+		payment.TicketNumber = tx.Meta(MetaTicketNumber)
+	}
+	return payment
+}
+
+// qrStatus traduce el estado de la transacción al estado de un pago QR
+func qrStatus(status store.Status) string {
+	switch status {
+	case store.StatusPaid:
+		return QRStatusConfirmed
+	case store.StatusFailed:
+		return QRStatusRejected
+	case store.StatusReversed:
+		return QRStatusReverted
+	case store.StatusCancelled, store.StatusExpired:
+		return QRStatusCancelled
+	default:
+		return QRStatusPending
+	}
+}
+
+// requestOrigin devuelve el esquema y host con el que se accedió al emulador
+func requestOrigin(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+func generateHookAlias() string {
+	const letters = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	return fmt.Sprintf("%c%c%08d", letters[rand.Intn(len(letters))], letters[rand.Intn(len(letters))], rand.Intn(100000000))
+}
@@ -0,0 +1,77 @@
+package bancard
+
+import (
+	"net/http"
+	"payment-emulator/internal/plugins"
+	"payment-emulator/internal/store"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// qrPath arma la ruta de la API de QR para el comercio y la sucursal indicados
+func qrPath(commerceCode, branchCode, operation string) string {
+	return "/external-commerce/api/0.1/commerces/" + commerceCode + "/branches/" + branchCode + "/selling/" + operation
+}
+
+// doQR envía una petición a la API de QR autenticada como el comercio
+func (s *testServer) doQR(merchant plugins.Merchant, method, path string, body interface{}, out interface{}) int {
+	s.t.Helper()
+	request := s.newRequest(method, path, body)
+	request.SetBasicAuth("apps/"+merchant.PublicKey, merchant.PrivateKey)
+	return s.serve(request, out)
+}
+
+// generateQR genera un QR del comercio en la sucursal y devuelve su hook_alias
+func (s *testServer) generateQR(merchant plugins.Merchant, commerceCode, branchCode string) string {
+	s.t.Helper()
+	var response BancardQRResponse
+	code := s.doQR(merchant, http.MethodPost, qrPath(commerceCode, branchCode, "generate-qr-express"), gin.H{"amount": 50000}, &response)
+	if code != http.StatusOK {
+		s.t.Fatalf("generate-qr-express = %d, want 200", code)
+	}
+	return response.QRExpress.HookAlias
+}
+
+func TestQRPaymentIsScopedToCommerceAndBranch(t *testing.T) {
+	s := newTestServer(t)
+	hookAlias := s.generateQR(merchantA, "2500", "48")
+	if code := s.do(http.MethodPost, "/emulator/bancard/qr/"+hookAlias+"/scan?result=success", nil, nil); code != http.StatusOK {
+		t.Fatalf("QR scan = %d, want 200", code)
+	}
+
+	var own BancardQRPaymentResponse
+	if code := s.doQR(merchantA, http.MethodGet, qrPath("2500", "48", "payments/"+hookAlias), nil, &own); code != http.StatusOK || own.Payment.Status != QRStatusConfirmed {
+		t.Fatalf("payments/%s by owner = %d %s, want 200 %s", hookAlias, code, own.Payment.Status, QRStatusConfirmed)
+	}
+
+	tests := []struct {
+		name     string
+		merchant plugins.Merchant
+		commerce string
+		branch   string
+	}{
+		{"otro comercio", merchantB, "2500", "48"},
+		{"otro commerce_code", merchantA, "9999", "48"},
+		{"otra sucursal", merchantA, "2500", "1"},
+	}
+
+	for _, tt := range tests {
+		var response BancardErrorResponse
+		if code := s.doQR(tt.merchant, http.MethodGet, qrPath(tt.commerce, tt.branch, "payments/"+hookAlias), nil, &response); code != http.StatusNotFound || errorKey(response) != ErrKeyPaymentNotFound {
+			t.Errorf("%s: payments = %d %s, want 404 %s", tt.name, code, errorKey(response), ErrKeyPaymentNotFound)
+		}
+		if code := s.doQR(tt.merchant, http.MethodDelete, qrPath(tt.commerce, tt.branch, "payments/revert/"+hookAlias), nil, &response); code != http.StatusNotFound || errorKey(response) != ErrKeyPaymentNotFound {
+			t.Errorf("%s: payments/revert = %d %s, want 404 %s", tt.name, code, errorKey(response), ErrKeyPaymentNotFound)
+		}
+	}
+
+	if tx := s.transaction(hookAlias); tx.Status != store.StatusPaid {
+		t.Fatalf("foreign revert left the QR payment %s, want paid", tx.Status)
+	}
+
+	var reverted BancardQRPaymentResponse
+	if code := s.doQR(merchantA, http.MethodDelete, qrPath("2500", "48", "payments/revert/"+hookAlias), nil, &reverted); code != http.StatusOK || reverted.Payment.Status != QRStatusReverted {
+		t.Errorf("payments/revert by owner = %d %s, want 200 %s", code, reverted.Payment.Status, QRStatusReverted)
+	}
+}
@@ -0,0 +1,117 @@
+package bancard
+
+import (
+	"math"
+	"net/http"
+	"payment-emulator/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleRefund maneja el reembolso total o parcial de un pago confirmado.
+// El pago pasa a refunded recién cuando se reembolsa el monto completo.
+func (p *BancardPlugin) handleRefund(c *gin.Context) {
+	var request BancardRefundRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+
+	tx, err := p.findPurchase(request.PublicKey, request.Operation.ShopProcessID)
+	if err != nil {
+		respondError(c, paymentNotFoundError(err))
+		return
+	}
+
+	_, err = p.verifyToken(request.PublicKey, request.Operation.Token, func(privateKey string) string {
+		return refundToken(privateKey, request.Operation.ShopProcessID, request.Operation.Amount)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := checkRefundable(tx); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Sin monto se reembolsa todo el saldo disponible
+	amount := refundableAmount(tx)
+	if request.Operation.Amount != "" {
+		amount, err = store.ParseAmount(request.Operation.Amount)
+		if err != nil || toCents(amount) == 0 {
+			respondError(c, newBancardError(http.StatusBadRequest, ErrKeyInvalidAmount, "Monto de reembolso inválido: "+request.Operation.Amount))
+			return
+		}
+	}
+
+	refund := func(tx *store.Transaction) error {
+		if err := checkRefundable(tx); err != nil {
+			return err
+		}
+		remaining := refundableAmount(tx)
+		if toCents(amount) > toCents(remaining) {
+			return newBancardError(http.StatusBadRequest, ErrKeyInvalidAmount, "El monto supera el saldo reembolsable ("+store.FormatAmount(remaining)+" "+tx.Currency+")")
+		}
+		tx.SetMeta(MetaRefundedAmount, store.FormatAmount(refundedAmount(tx)+amount))
+		return nil
+	}
+
+	if toCents(amount) == toCents(refundableAmount(tx)) {
+		tx, err = p.store.Transition(GatewayName, tx.ID, store.StatusRefunded, "Reembolso total de "+store.FormatAmount(amount), refund)
+	} else {
+		tx, err = p.store.Update(GatewayName, tx.ID, refund)
+	}
+	if err != nil {
+		respondError(c, paymentNotFoundError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, BancardRefundResponse{
+		Status: StatusSuccess,
+		Messages: []BancardMessage{
+			{Key: "RefundSuccessful", Level: MessageLevelInfo, Dsc: "Reembolso realizado con éxito."},
+		},
+		Refund: BancardRefundDetails{
+			ShopProcessID:    tx.Reference,
+			Amount:           store.FormatAmount(amount),
+			Currency:         tx.Currency,
+			RefundedAmount:   store.FormatAmount(refundedAmount(tx)),
+			RefundableAmount: store.FormatAmount(refundableAmount(tx)),
+		},
+	})
+}
+
+// checkRefundable valida que la transacción admita reembolsos
+func checkRefundable(tx *store.Transaction) error {
+	switch tx.Status {
+	case store.StatusPaid:
+		return nil
+	case store.StatusRefunded:
+		return newBancardError(http.StatusBadRequest, ErrKeyInvalidAmount, "El pago ya fue reembolsado en su totalidad")
+	case store.StatusReversed, store.StatusCancelled:
+		return alreadyProcessedError(tx.Status)
+	default:
+		return newBancardError(http.StatusBadRequest, ErrKeyPaymentNotConfirmed, "El pago no fue confirmado")
+	}
+}
+
+// refundedAmount devuelve el monto ya reembolsado de la transacción
+func refundedAmount(tx *store.Transaction) float64 {
+	refunded, err := store.ParseAmount(tx.Meta(MetaRefundedAmount))
+	if err != nil {
+		return 0
+	}
+	return refunded
+}
+
+// refundableAmount devuelve el saldo que todavía puede reembolsarse
+func refundableAmount(tx *store.Transaction) float64 {
+	return tx.Amount - refundedAmount(tx)
+}
+
+// toCents convierte un monto a centavos para compararlo sin errores de redondeo
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
@@ -0,0 +1,60 @@
+package bancard
+
+import (
+	"net/http"
+	"payment-emulator/internal/store"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// refund reembolsa la compra de merchantA; sin monto se reembolsa el saldo restante
+func (s *testServer) refund(shopProcessID, amount string, out interface{}) int {
+	s.t.Helper()
+	fields := gin.H{"token": refundToken(merchantA.PrivateKey, shopProcessID, amount), "shop_process_id": shopProcessID}
+	if amount != "" {
+		fields["amount"] = amount
+	}
+	return s.do(http.MethodPost, "/vpos/api/0.3/refund", operation(merchantA, fields), out)
+}
+
+func TestRefundOfRemainingBalance(t *testing.T) {
+	s := newTestServer(t)
+	processID := s.singleBuy(merchantA, "7007", "10000.00")
+	s.pay(processID)
+
+	var partial BancardRefundResponse
+	if code := s.refund("7007", "4000.00", &partial); code != http.StatusOK {
+		t.Fatalf("partial refund = %d, want 200", code)
+	}
+	if partial.Refund.RefundedAmount != "4000.00" || partial.Refund.RefundableAmount != "6000.00" {
+		t.Errorf("partial refund left %s refunded and %s refundable, want 4000.00 and 6000.00", partial.Refund.RefundedAmount, partial.Refund.RefundableAmount)
+	}
+	if tx := s.transaction(processID); tx.Status != store.StatusPaid {
+		t.Errorf("purchase is %s after a partial refund, want %s", tx.Status, store.StatusPaid)
+	}
+
+	for _, amount := range []string{"6000.01", "0", "0.00", "-1.00", "NaN"} {
+		var response BancardErrorResponse
+		if code := s.refund("7007", amount, &response); code != http.StatusBadRequest || errorKey(response) != ErrKeyInvalidAmount {
+			t.Errorf("refund of %s = %d %s, want 400 %s", amount, code, errorKey(response), ErrKeyInvalidAmount)
+		}
+	}
+
+	// Sin monto se reembolsa el saldo restante y la compra queda reembolsada
+	var remaining BancardRefundResponse
+	if code := s.refund("7007", "", &remaining); code != http.StatusOK {
+		t.Fatalf("refund of remaining balance = %d, want 200", code)
+	}
+	if remaining.Refund.Amount != "6000.00" || remaining.Refund.RefundedAmount != "10000.00" || remaining.Refund.RefundableAmount != "0.00" {
+		t.Errorf("refund of remaining balance = %+v, want 6000.00 refunded for a total of 10000.00", remaining.Refund)
+	}
+	if tx := s.transaction(processID); tx.Status != store.StatusRefunded {
+		t.Errorf("purchase is %s after refunding the remaining balance, want %s", tx.Status, store.StatusRefunded)
+	}
+
+	var again BancardErrorResponse
+	if code := s.refund("7007", "", &again); code != http.StatusBadRequest {
+		t.Errorf("refund of a fully refunded purchase = %d %s, want 400", code, errorKey(again))
+	}
+}
@@ -0,0 +1,100 @@
+package bancard
+
+import (
+	"net/http"
+	"payment-emulator/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseCode representa un código de respuesta del procesador (ISO 8583) con sus descripciones
+type ResponseCode struct {
+	Code                string `json:"response_code"`
+	Description         string `json:"response_description"`
+	ExtendedDescription string `json:"extended_response_description"`
+	Retryable           bool   `json:"retryable"` // El rechazo es temporal: el comprador puede reintentar más tarde
+}
+
+// Approved indica si el código corresponde a una transacción aprobada
+func (r ResponseCode) Approved() bool {
+	return r.Code == ResponseCodeApproved
+}
+
+// ResponseCodeApproved es el código de una transacción aprobada
+const ResponseCodeApproved = "00"
+
+// DefaultDeclineCode es el código usado cuando se simula un rechazo sin indicar código
+const DefaultDeclineCode = "05"
+
+// responseCodes es el catálogo de códigos de respuesta que devuelve Bancard
+var responseCodes = []ResponseCode{
+	{Code: "00", Description: "Transacción aprobada", ExtendedDescription: "Transacción aprobada"},
+	{Code: "01", Description: "Referirse al emisor", ExtendedDescription: "El banco emisor requiere autorización telefónica. El comprador debe contactar a su banco."},
+	{Code: "04", Description: "Retener tarjeta", ExtendedDescription: "El banco emisor solicitó retener la tarjeta. No reintentar con esta tarjeta."},
+	{Code: "05", Description: "No aprobada, contacte a su banco emisor", ExtendedDescription: "El banco emisor rechazó la transacción sin indicar el motivo. El comprador debe contactar a su banco o usar otra tarjeta."},
+	{Code: "12", Description: "Transacción inválida", ExtendedDescription: "La tarjeta no está habilitada para este tipo de transacción."},
+	{Code: "13", Description: "Monto inválido", ExtendedDescription: "El monto de la transacción no es válido para la tarjeta."},
+	{Code: "14", Description: "Tarjeta inválida", ExtendedDescription: "El número de tarjeta no existe en el banco emisor."},
+	{Code: "19", Description: "Reintente la transacción", ExtendedDescription: "Error temporal del procesador. La transacción puede reintentarse.", Retryable: true},
+	{Code: "41", Description: "Tarjeta extraviada", ExtendedDescription: "La tarjeta fue denunciada como extraviada. No reintentar con esta tarjeta."},
+	{Code: "43", Description: "Tarjeta robada", ExtendedDescription: "La tarjeta fue denunciada como robada. No reintentar con esta tarjeta."},
+	{Code: "51", Description: "Fondos insuficientes", ExtendedDescription: "La tarjeta no tiene saldo o línea de crédito suficiente para el monto de la compra.", Retryable: true},
+	{Code: "54", Description: "Tarjeta vencida", ExtendedDescription: "La fecha de vencimiento de la tarjeta ya pasó. El comprador debe usar otra tarjeta."},
+	{Code: "55", Description: "PIN incorrecto", ExtendedDescription: "El PIN ingresado no es correcto.", Retryable: true},
+	{Code: "57", Description: "Transacción no permitida al tarjetahabiente", ExtendedDescription: "La tarjeta no está habilitada para compras por internet. El comprador debe habilitarla con su banco."},
+	{Code: "58", Description: "Transacción no permitida a la terminal", ExtendedDescription: "El comercio no está habilitado para este tipo de tarjeta o transacción."},
+	{Code: "61", Description: "Excede el límite de monto", ExtendedDescription: "El monto supera el límite de compra diario de la tarjeta.", Retryable: true},
+	{Code: "62", Description: "Tarjeta restringida", ExtendedDescription: "La tarjeta tiene restricciones de uso definidas por el banco emisor."},
+	{Code: "65", Description: "Excede la cantidad de transacciones", ExtendedDescription: "Se superó la cantidad de transacciones diarias permitidas para la tarjeta.", Retryable: true},
+	{Code: "91", Description: "Emisor no disponible", ExtendedDescription: "El banco emisor no respondió a tiempo. La transacción puede reintentarse en unos minutos.", Retryable: true},
+	{Code: "96", Description: "Error del sistema", ExtendedDescription: "Mal funcionamiento del sistema del procesador. La transacción puede reintentarse en unos minutos.", Retryable: true},
+}
+
+// findResponseCode busca un código en el catálogo
+func findResponseCode(code string) (ResponseCode, bool) {
+	for _, responseCode := range responseCodes {
+		if responseCode.Code == code {
+			return responseCode, true
+		}
+	}
+	return ResponseCode{}, false
+}
+
+// responseCodeFor devuelve el código del catálogo. Una descripción no vacía reemplaza la del catálogo
+// (tarjetas de prueba configuradas) y los códigos desconocidos se describen solo con ella.
+func responseCodeFor(code, description string) ResponseCode {
+	responseCode, ok := findResponseCode(code)
+	if !ok {
+		responseCode = ResponseCode{Code: code, ExtendedDescription: description}
+	}
+	if description != "" {
+		responseCode.Description = description
+	}
+	return responseCode
+}
+
+// declineCodes devuelve los códigos de rechazo del catálogo
+func declineCodes() []ResponseCode {
+	codes := make([]ResponseCode, 0, len(responseCodes)-1)
+	for _, responseCode := range responseCodes {
+		if !responseCode.Approved() {
+			codes = append(codes, responseCode)
+		}
+	}
+	return codes
+}
+
+// setResponseCodeMeta registra en la transacción el código de respuesta y sus descripciones
+func setResponseCodeMeta(tx *store.Transaction, responseCode ResponseCode) {
+	tx.SetMeta(MetaResponseCode, responseCode.Code)
+	tx.SetMeta(MetaResponseDescription, responseCode.Description)
+	tx.SetMeta(MetaExtendedResponseDescription, responseCode.ExtendedDescription)
+}
+
+// handleEmulatorResponseCodes lista el catálogo de códigos de respuesta
+func (p *BancardPlugin) handleEmulatorResponseCodes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":         StatusSuccess,
+		"response_codes": responseCodes,
+	})
+}
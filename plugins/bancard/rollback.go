@@ -0,0 +1,75 @@
+package bancard
+
+import (
+	"errors"
+	"net/http"
+	"payment-emulator/internal/clock"
+	"payment-emulator/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleRollback maneja la reversa de un pago (single_buy/rollback).
+// Un pago sin confirmar se cancela; uno confirmado se reversa si no superó el RollbackWindow
+// ni tiene reembolsos parciales.
+func (p *BancardPlugin) handleRollback(c *gin.Context) {
+	var request BancardRollbackRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, bindingError(err))
+		return
+	}
+
+	tx, err := p.findPurchase(request.PublicKey, request.Operation.ShopProcessID)
+	if err != nil {
+		respondError(c, paymentNotFoundError(err))
+		return
+	}
+
+	_, err = p.verifyToken(request.PublicKey, request.Operation.Token, func(privateKey string) string {
+		return rollbackToken(privateKey, request.Operation.ShopProcessID)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	to := store.StatusCancelled
+	if tx.Status == store.StatusPaid {
+		to = store.StatusReversed
+	}
+
+	_, err = p.store.Transition(GatewayName, tx.ID, to, "Rollback solicitado por el comercio", func(tx *store.Transaction) error {
+		if tx.Status != store.StatusPaid {
+			return nil
+		}
+		// Un pago con reembolsos parciales ya no puede reversarse por el monto original
+		if refunded := refundedAmount(tx); refunded > 0 {
+			return newBancardError(http.StatusBadRequest, ErrKeyAlreadyConfirmed,
+				"La transacción tiene reembolsos por "+store.FormatAmount(refunded)+" y no puede reversarse")
+		}
+		paidAt, _ := tx.StatusAt(store.StatusPaid)
+		if clock.Now().Sub(paidAt) > RollbackWindow {
+			return newBancardError(http.StatusBadRequest, ErrKeyAlreadyConfirmed, "La transacción ya fue confirmada y superó el plazo de reversa")
+		}
+		return nil
+	})
+	if err != nil {
+		respondError(c, paymentNotFoundError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, BancardRollbackResponse{
+		Status: StatusSuccess,
+		Messages: []BancardMessage{
+			{Key: "RollbackSuccessful", Level: MessageLevelInfo, Dsc: "Reversa realizada con éxito."},
+		},
+	})
+}
+
+// paymentNotFoundError traduce store.ErrNotFound al error PaymentNotFoundError de Bancard
+func paymentNotFoundError(err error) error {
+	if errors.Is(err, store.ErrNotFound) {
+		return newBancardError(http.StatusNotFound, ErrKeyPaymentNotFound, "Pago no encontrado")
+	}
+	return err
+}
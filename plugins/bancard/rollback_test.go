@@ -0,0 +1,56 @@
+package bancard
+
+import (
+	"net/http"
+	"payment-emulator/internal/clock"
+	"payment-emulator/internal/store"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rollback reversa la compra de merchantA
+func (s *testServer) rollback(shopProcessID string, out interface{}) int {
+	s.t.Helper()
+	return s.do(http.MethodPost, "/vpos/api/0.3/single_buy/rollback", operation(merchantA, gin.H{
+		"token": rollbackToken(merchantA.PrivateKey, shopProcessID), "shop_process_id": shopProcessID,
+	}), out)
+}
+
+func TestRollbackWindow(t *testing.T) {
+	s := newTestServer(t)
+	t.Cleanup(func() { clock.GetGlobalClock().Reset() })
+
+	// Dentro del plazo la compra confirmada se reversa
+	inWindow := s.singleBuy(merchantA, "9009", "10000.00")
+	s.pay(inWindow)
+	clock.GetGlobalClock().Advance(RollbackWindow - time.Minute)
+	if code := s.rollback("9009", nil); code != http.StatusOK {
+		t.Fatalf("rollback within %s = %d, want 200", RollbackWindow, code)
+	}
+	if tx := s.transaction(inWindow); tx.Status != store.StatusReversed {
+		t.Errorf("purchase rolled back within the window is %s, want %s", tx.Status, store.StatusReversed)
+	}
+
+	// Vencido el plazo solo puede reembolsarse
+	expired := s.singleBuy(merchantA, "9010", "10000.00")
+	s.pay(expired)
+	clock.GetGlobalClock().Advance(RollbackWindow + time.Minute)
+	var response BancardErrorResponse
+	if code := s.rollback("9010", &response); code != http.StatusBadRequest || errorKey(response) != ErrKeyAlreadyConfirmed {
+		t.Errorf("rollback after %s = %d %s, want 400 %s", RollbackWindow, code, errorKey(response), ErrKeyAlreadyConfirmed)
+	}
+	if tx := s.transaction(expired); tx.Status != store.StatusPaid {
+		t.Errorf("purchase rolled back after the window is %s, want %s", tx.Status, store.StatusPaid)
+	}
+
+	// Una compra sin confirmar se cancela sin importar el plazo
+	unpaid := s.singleBuy(merchantA, "9011", "10000.00")
+	if code := s.rollback("9011", nil); code != http.StatusOK {
+		t.Fatalf("rollback of an unpaid purchase = %d, want 200", code)
+	}
+	if tx := s.transaction(unpaid); tx.Status != store.StatusCancelled {
+		t.Errorf("unpaid purchase rolled back is %s, want %s", tx.Status, store.StatusCancelled)
+	}
+}
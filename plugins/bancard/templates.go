@@ -3,9 +3,12 @@ package bancard
 // GetBancardTemplates devuelve todos los templates específicos de Bancard
 func GetBancardTemplates() map[string]string {
 	return map[string]string{
-		"bancard_checkout.html": bancardCheckoutHTML,
-		"bancard_result.html":   bancardResultHTML,
-		"bancard_docs.html":     bancardDocsHTML,
+		"bancard_checkout.html":  bancardCheckoutHTML,
+		"bancard_result.html":    bancardResultHTML,
+		"bancard_docs.html":      bancardDocsHTML,
+		"bancard_card_form.html": bancardCardFormHTML,
+		"bancard_zimple.html":    bancardZimpleHTML,
+		"bancard_qr.html":        bancardQRHTML,
 	}
 }
 
@@ -25,7 +28,7 @@ const bancardCheckoutHTML = `<!DOCTYPE html>
         .card-form { margin: 20px 0; }
         .form-group { margin-bottom: 15px; }
         .form-group label { display: block; margin-bottom: 5px; font-weight: bold; color: #333; }
-        .form-group input { width: 100%; padding: 12px; border: 1px solid #ddd; border-radius: 6px; font-size: 16px; }
+        .form-group input, .form-group select { width: 100%; padding: 12px; border: 1px solid #ddd; border-radius: 6px; font-size: 16px; }
         .form-row { display: flex; gap: 15px; }
         .form-row .form-group { flex: 1; }
         .actions { text-align: center; margin-top: 30px; }
@@ -35,6 +38,24 @@ const bancardCheckoutHTML = `<!DOCTYPE html>
         .error { background: #dc3545; color: white; }
         .primary:hover { background: #2a5298; }
         .security-info { background: #e7f3ff; padding: 15px; border-radius: 8px; margin-top: 20px; border-left: 4px solid #007bff; }
+        .form-group input.invalid { border-color: #dc3545; }
+        .field-error { color: #dc3545; font-size: 13px; margin-top: 4px; min-height: 0; }
+        .form-alert { display: none; background: #f8d7da; color: #721c24; padding: 12px; border-radius: 6px; margin-bottom: 15px; }
+    </style>
+    {{if .embedded}}
+    <style>
+        body { background: transparent; }
+        .container { padding: 0; }
+    </style>
+    {{end}}
+    <style>
+        {{with index .styles "form-background-color"}}.checkout-card { background: {{.}}; }{{end}}
+        {{with index .styles "button-background-color"}}.primary, .primary:hover { background: {{.}}; }{{end}}
+        {{with index .styles "button-text-color"}}.primary { color: {{.}}; }{{end}}
+        {{with index .styles "button-border-color"}}.primary { border: 1px solid {{.}}; }{{end}}
+        {{with index .styles "input-background-color"}}.form-group input, .form-group select { background: {{.}}; }{{end}}
+        {{with index .styles "input-text-color"}}.form-group input, .form-group select { color: {{.}}; }{{end}}
+        {{with index .styles "input-placeholder-color"}}.form-group input::placeholder { color: {{.}}; }{{end}}
     </style>
 </head>
 <body>
@@ -51,14 +72,17 @@ const bancardCheckoutHTML = `<!DOCTYPE html>
                 <p><strong>Monto:</strong> ₲ {{.data.Amount}}</p>
                 <p><strong>Moneda:</strong> {{.data.Currency}}</p>
                 <p><strong>Descripción:</strong> {{.data.OrderDetails.Description}}</p>
+                {{with .promotion}}<p><strong>Promoción:</strong> {{.Code}} - {{.Description}}</p>{{end}}
             </div>
             
             <div class="card-form">
                 <h3>💳 Información de la Tarjeta</h3>
+                <div class="form-alert" id="form-alert"></div>
                 <div class="form-group">
                     <label for="card-number">Número de Tarjeta</label>
-                    <input type="text" id="card-number" placeholder="1234 5678 9012 3456" maxlength="19" 
-                           oninput="formatCardNumber(this)">
+                    <input type="text" id="card-number" placeholder="1234 5678 9012 3456" maxlength="23" 
+                           oninput="formatCardNumber(this); updateInstallments()">
+                    <div class="field-error" id="card-number-error"></div>
                 </div>
                 
                 <div class="form-row">
@@ -66,13 +90,21 @@ const bancardCheckoutHTML = `<!DOCTYPE html>
                         <label for="expiry">Vencimiento</label>
                         <input type="text" id="expiry" placeholder="MM/AA" maxlength="5" 
                                oninput="formatExpiry(this)">
+                        <div class="field-error" id="expiry-error"></div>
                     </div>
                     <div class="form-group">
                         <label for="cvv">CVV</label>
                         <input type="text" id="cvv" placeholder="123" maxlength="4">
+                        <div class="field-error" id="cvv-error"></div>
                     </div>
                 </div>
                 
+                <div class="form-group">
+                    <label for="installments">Cuotas</label>
+                    <select id="installments" onchange="this.dataset.chosen = this.value"></select>
+                    <div class="field-error" id="installments-error"></div>
+                </div>
+                
                 <div class="form-group">
                     <label for="cardholder">Titular de la Tarjeta</label>
                     <input type="text" id="cardholder" placeholder="JUAN PEREZ" style="text-transform: uppercase;">
@@ -95,6 +127,13 @@ const bancardCheckoutHTML = `<!DOCTYPE html>
                 <button class="button error" onclick="processPayment('error')">
                     ❌ Simular Error
                 </button>
+                <div class="form-group">
+                    <label for="response-code">Código de rechazo simulado</label>
+                    <select id="response-code">
+                        {{range .response_codes}}<option value="{{.Code}}"{{if eq .Code "05"}} selected{{end}}>{{.Code}} - {{.Description}}</option>
+                        {{end}}
+                    </select>
+                </div>
                 <button class="button secondary" onclick="processPayment('cancel')">
                     🚫 Cancelar
                 </button>
@@ -117,28 +156,581 @@ const bancardCheckoutHTML = `<!DOCTYPE html>
             input.value = value;
         }
 
+        // Campos del formulario asociados a los errores de validación de Bancard
+        const fieldErrors = {
+            InvalidCardNumberError: 'card-number',
+            InvalidExpirationDateError: 'expiry',
+            InvalidCvvError: 'cvv',
+            InvalidInstallmentsError: 'installments'
+        };
+
+        // Opciones de cuotas habilitadas para el monto, filtradas por la marca de la tarjeta
+        const installmentOptions = {{.installments}};
+        const presetInstallments = '{{.preset}}';
+
+        function cardBrand(number) {
+            if (/^3[47]/.test(number)) return 'AMEX';
+            if (/^4/.test(number)) return 'VISA';
+            if (/^[52]/.test(number)) return 'MASTERCARD';
+            return '';
+        }
+
+        function updateInstallments() {
+            const select = document.getElementById('installments');
+            const selected = select.dataset.chosen || presetInstallments;
+            const brand = cardBrand(document.getElementById('card-number').value.replace(/\D/g, ''));
+
+            select.innerHTML = '';
+            select.add(new Option('1 pago', '1'));
+            installmentOptions
+                .filter(option => !option.brand || option.brand === brand)
+                .forEach(option => select.add(new Option(option.label, String(option.installments))));
+            if (selected && Array.from(select.options).some(option => option.value === selected)) {
+                select.value = selected;
+            }
+        }
+
+        updateInstallments();
+
+        function luhnValid(number) {
+            let sum = 0;
+            for (let i = 0; i < number.length; i++) {
+                let digit = parseInt(number.charAt(number.length - 1 - i), 10);
+                if (i % 2 === 1) {
+                    digit *= 2;
+                    if (digit > 9) digit -= 9;
+                }
+                sum += digit;
+            }
+            return sum % 10 === 0;
+        }
+
+        function expiryValid(expiry) {
+            const match = /^(\d{2})\/(\d{2})$/.exec(expiry);
+            if (!match) return false;
+            const month = parseInt(match[1], 10);
+            const year = 2000 + parseInt(match[2], 10);
+            if (month < 1 || month > 12) return false;
+            return new Date() < new Date(year, month, 1);
+        }
+
+        function showFieldError(field, message) {
+            document.getElementById(field).classList.add('invalid');
+            document.getElementById(field + '-error').textContent = message;
+        }
+
+        function clearErrors() {
+            Object.values(fieldErrors).forEach(field => {
+                document.getElementById(field).classList.remove('invalid');
+                document.getElementById(field + '-error').textContent = '';
+            });
+            document.getElementById('form-alert').style.display = 'none';
+        }
+
+        function showAlert(message) {
+            const alertBox = document.getElementById('form-alert');
+            alertBox.textContent = message;
+            alertBox.style.display = 'block';
+        }
+
+        function validateCard(card) {
+            let valid = true;
+            const number = card.card_number;
+            if (number.length < 13 || number.length > 19 || !luhnValid(number)) {
+                showFieldError('card-number', 'Número de tarjeta inválido');
+                valid = false;
+            }
+            if (!expiryValid(card.expiration_date)) {
+                showFieldError('expiry', 'Fecha de vencimiento inválida');
+                valid = false;
+            }
+            const cvvLength = /^3[47]/.test(number) ? 4 : 3;
+            if (!new RegExp('^\\d{' + cvvLength + '}$').test(card.cvv)) {
+                showFieldError('cvv', 'Código de seguridad inválido');
+                valid = false;
+            }
+            return valid;
+        }
+
+        function resetButton() {
+            document.querySelector('.primary').innerHTML = '✅ Procesar Pago (₲ {{.data.Amount}})';
+            document.querySelector('.primary').disabled = false;
+        }
+
+        // Dentro del iframe de bancard-checkout.js la redirección la hace la página del comercio
+        function redirectTo(url) {
+            if (window.parent !== window) {
+                window.parent.postMessage({
+                    source: 'bancard-emulator',
+                    action: 'redirect',
+                    url: new URL(url, window.location.href).href
+                }, '*');
+                return;
+            }
+            window.location.href = url;
+        }
+
         function processPayment(result) {
             const processId = '{{.data.ProcessID}}';
-            
+            const request = { method: 'POST' };
+            clearErrors();
+
             if (result === 'success') {
-                // Simular procesamiento
+                const card = {
+                    card_number: document.getElementById('card-number').value.replace(/\D/g, ''),
+                    expiration_date: document.getElementById('expiry').value,
+                    cvv: document.getElementById('cvv').value,
+                    cardholder: document.getElementById('cardholder').value,
+                    document: document.getElementById('document').value,
+                    number_of_payments: parseInt(document.getElementById('installments').value, 10)
+                };
+                if (!validateCard(card)) {
+                    return;
+                }
+                request.headers = { 'Content-Type': 'application/json' };
+                request.body = JSON.stringify(card);
+
                 document.querySelector('.primary').innerHTML = '⏳ Procesando...';
                 document.querySelector('.primary').disabled = true;
-                
-                setTimeout(() => {
-                    // Simular respuesta exitosa
-                    const returnUrl = '{{.data.ReturnURL}}' || '/bancard/return';
-                    const transactionId = 'TXN' + Math.random().toString(36).substr(2, 9).toUpperCase();
-                    window.location.href = returnUrl + '?status=success&transaction_id=' + transactionId + '&process_id=' + processId;
-                }, 2000);
-            } else if (result === 'error') {
-                alert('Simulando error: Tarjeta rechazada por el banco emisor');
-                const returnUrl = '{{.data.ReturnURL}}' || '/bancard/return';
-                window.location.href = returnUrl + '?status=error&error_code=05&process_id=' + processId;
-            } else {
-                const cancelUrl = '{{.data.CancelURL}}' || '/bancard/cancel';
-                window.location.href = cancelUrl + '?status=cancelled&process_id=' + processId;
             }
+
+            let query = '?result=' + result;
+            if (result === 'error') {
+                query += '&response_code=' + document.getElementById('response-code').value;
+            }
+
+            fetch('/emulator/bancard/' + processId + query, request)
+            .then(response => response.json())
+            .then(data => {
+                if (data.redirect_url) {
+                    redirectTo(data.redirect_url);
+                    return;
+                }
+                resetButton();
+                const error = data.messages ? data.messages[0] : { key: '', dsc: data.message };
+                if (fieldErrors[error.key]) {
+                    showFieldError(fieldErrors[error.key], error.dsc);
+                } else {
+                    showAlert(error.dsc);
+                }
+            });
+        }
+    </script>
+</body>
+</html>`
+
+// Template para el iframe de catastro de tarjetas
+const bancardCardFormHTML = `<!DOCTYPE html>
+<html>
+<head>
+    <title>Bancard VPOS - Catastro de Tarjeta</title>
+    <meta charset="utf-8">
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; background: linear-gradient(135deg, #1e3c72 0%, #2a5298 100%); min-height: 100vh; }
+        .container { max-width: 500px; margin: 0 auto; padding: 40px 20px; }
+        .checkout-card { background: white; border-radius: 12px; padding: 30px; box-shadow: 0 10px 30px rgba(0,0,0,0.2); }
+        .logo { text-align: center; margin-bottom: 30px; }
+        .logo h1 { color: #1e3c72; margin: 0; font-size: 28px; }
+        .order-info { background: #f8f9fa; padding: 20px; border-radius: 8px; margin-bottom: 30px; }
+        .form-group { margin-bottom: 15px; }
+        .form-group label { display: block; margin-bottom: 5px; font-weight: bold; color: #333; }
+        .form-group input, .form-group select { width: 100%; padding: 12px; border: 1px solid #ddd; border-radius: 6px; font-size: 16px; }
+        .form-row { display: flex; gap: 15px; }
+        .form-row .form-group { flex: 1; }
+        .actions { text-align: center; margin-top: 30px; }
+        .button { display: inline-block; padding: 15px 30px; margin: 10px; border: none; border-radius: 8px; font-size: 16px; cursor: pointer; text-decoration: none; font-weight: bold; }
+        .primary { background: #1e3c72; color: white; }
+        .secondary { background: #6c757d; color: white; }
+        .error { background: #dc3545; color: white; }
+    </style>
+    {{if .embedded}}
+    <style>
+        body { background: transparent; }
+        .container { padding: 0; }
+    </style>
+    {{end}}
+    <style>
+        {{with index .styles "form-background-color"}}.checkout-card { background: {{.}}; }{{end}}
+        {{with index .styles "button-background-color"}}.primary, .primary:hover { background: {{.}}; }{{end}}
+        {{with index .styles "button-text-color"}}.primary { color: {{.}}; }{{end}}
+        {{with index .styles "button-border-color"}}.primary { border: 1px solid {{.}}; }{{end}}
+        {{with index .styles "input-background-color"}}.form-group input, .form-group select { background: {{.}}; }{{end}}
+        {{with index .styles "input-text-color"}}.form-group input, .form-group select { color: {{.}}; }{{end}}
+        {{with index .styles "input-placeholder-color"}}.form-group input::placeholder { color: {{.}}; }{{end}}
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="checkout-card">
+            <div class="logo">
+                <h1>💳 Bancard VPOS</h1>
+                <p>Catastro de Tarjeta</p>
+            </div>
+            
+            <div class="order-info">
+                <p><strong>Process ID:</strong> {{.card.ID}}</p>
+                <p><strong>Usuario:</strong> {{.card.UserID}}</p>
+                <p><strong>Card ID:</strong> {{.card.CardID}}</p>
+            </div>
+            
+            <div class="form-group">
+                <label for="card-number">Número de Tarjeta</label>
+                <input type="text" id="card-number" placeholder="4111 1111 1111 1111" maxlength="19" oninput="formatCardNumber(this)">
+            </div>
+            
+            <div class="form-row">
+                <div class="form-group">
+                    <label for="expiry">Vencimiento</label>
+                    <input type="text" id="expiry" placeholder="MM/AA" maxlength="5" oninput="formatExpiry(this)">
+                </div>
+                <div class="form-group">
+                    <label for="cvv">CVV</label>
+                    <input type="text" id="cvv" placeholder="123" maxlength="4">
+                </div>
+                <div class="form-group">
+                    <label for="card-type">Tipo</label>
+                    <select id="card-type">
+                        <option value="credit">Crédito</option>
+                        <option value="debit">Débito</option>
+                    </select>
+                </div>
+            </div>
+            
+            <div class="actions">
+                <button class="button primary" onclick="registerCard('success')">✅ Catastrar Tarjeta</button>
+                <button class="button error" onclick="registerCard('error')">❌ Simular Error</button>
+                <button class="button secondary" onclick="registerCard('cancel')">🚫 Cancelar</button>
+            </div>
+        </div>
+    </div>
+
+    <script>
+        function formatCardNumber(input) {
+            let value = input.value.replace(/\s/g, '').replace(/[^0-9]/gi, '');
+            input.value = value.match(/.{1,4}/g)?.join(' ') || value;
+        }
+
+        function formatExpiry(input) {
+            let value = input.value.replace(/\D/g, '');
+            if (value.length >= 2) {
+                value = value.substring(0, 2) + '/' + value.substring(2, 4);
+            }
+            input.value = value;
+        }
+
+        // Dentro del iframe de bancard-checkout.js la redirección la hace la página del comercio
+        function redirectTo(url) {
+            if (window.parent !== window) {
+                window.parent.postMessage({
+                    source: 'bancard-emulator',
+                    action: 'redirect',
+                    url: new URL(url, window.location.href).href
+                }, '*');
+                return;
+            }
+            window.location.href = url;
+        }
+
+        function registerCard(result) {
+            fetch('/emulator/bancard/cards/{{.card.ID}}', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({
+                    result: result,
+                    card_number: document.getElementById('card-number').value,
+                    expiration_date: document.getElementById('expiry').value,
+                    cvv: document.getElementById('cvv').value,
+                    card_type: document.getElementById('card-type').value
+                })
+            }).then(response => response.json())
+            .then(data => {
+                if (!data.redirect_url) {
+                    alert(data.messages ? data.messages[0].dsc : data.message);
+                    return;
+                }
+                redirectTo(data.redirect_url);
+            });
+        }
+    </script>
+</body>
+</html>`
+
+// Template para el checkout con la billetera Zimple
+const bancardZimpleHTML = `<!DOCTYPE html>
+<html>
+<head>
+    <title>Bancard VPOS - Zimple</title>
+    <meta charset="utf-8">
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; background: linear-gradient(135deg, #1e3c72 0%, #2a5298 100%); min-height: 100vh; }
+        .container { max-width: 500px; margin: 0 auto; padding: 40px 20px; }
+        .checkout-card { background: white; border-radius: 12px; padding: 30px; box-shadow: 0 10px 30px rgba(0,0,0,0.2); }
+        .logo { text-align: center; margin-bottom: 30px; }
+        .logo h1 { color: #1e3c72; margin: 0; font-size: 28px; }
+        .order-info { background: #f8f9fa; padding: 20px; border-radius: 8px; margin-bottom: 30px; }
+        .card-form { margin: 20px 0; }
+        .form-group { margin-bottom: 15px; }
+        .form-group label { display: block; margin-bottom: 5px; font-weight: bold; color: #333; }
+        .form-group input, .form-group select { width: 100%; padding: 12px; border: 1px solid #ddd; border-radius: 6px; font-size: 16px; }
+        .form-row { display: flex; gap: 15px; }
+        .form-row .form-group { flex: 1; }
+        .actions { text-align: center; margin-top: 30px; }
+        .button { display: inline-block; padding: 15px 30px; margin: 10px; border: none; border-radius: 8px; font-size: 16px; cursor: pointer; text-decoration: none; font-weight: bold; }
+        .primary { background: #1e3c72; color: white; }
+        .secondary { background: #6c757d; color: white; }
+        .error { background: #dc3545; color: white; }
+        .primary:hover { background: #2a5298; }
+        .security-info { background: #e7f3ff; padding: 15px; border-radius: 8px; margin-top: 20px; border-left: 4px solid #007bff; }
+        .form-group input.invalid { border-color: #dc3545; }
+        .field-error { color: #dc3545; font-size: 13px; margin-top: 4px; min-height: 0; }
+        .step { display: none; }
+        .step.active { display: block; }
+        .hint { color: #6c757d; font-size: 14px; }
+        .form-alert { display: none; background: #f8d7da; color: #721c24; padding: 12px; border-radius: 6px; margin-bottom: 15px; }
+    </style>
+    {{if .embedded}}
+    <style>
+        body { background: transparent; }
+        .container { padding: 0; }
+    </style>
+    {{end}}
+    <style>
+        {{with index .styles "form-background-color"}}.checkout-card { background: {{.}}; }{{end}}
+        {{with index .styles "button-background-color"}}.primary, .primary:hover { background: {{.}}; }{{end}}
+        {{with index .styles "button-text-color"}}.primary { color: {{.}}; }{{end}}
+        {{with index .styles "button-border-color"}}.primary { border: 1px solid {{.}}; }{{end}}
+        {{with index .styles "input-background-color"}}.form-group input, .form-group select { background: {{.}}; }{{end}}
+        {{with index .styles "input-text-color"}}.form-group input, .form-group select { color: {{.}}; }{{end}}
+        {{with index .styles "input-placeholder-color"}}.form-group input::placeholder { color: {{.}}; }{{end}}
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="checkout-card">
+            <div class="logo">
+                <h1>📱 Zimple</h1>
+                <p>Pago con billetera - Bancard VPOS</p>
+            </div>
+            
+            <div class="order-info">
+                <h3>📋 Información de la Transacción</h3>
+                <p><strong>Process ID:</strong> {{.data.ProcessID}}</p>
+                <p><strong>Monto:</strong> ₲ {{.data.Amount}}</p>
+                <p><strong>Descripción:</strong> {{.data.OrderDetails.Description}}</p>
+                {{with .promotion}}<p><strong>Promoción:</strong> {{.Code}} - {{.Description}}</p>{{end}}
+            </div>
+            
+            <div class="form-alert" id="form-alert"></div>
+            
+            <div class="step active" id="step-phone">
+                <div class="form-group">
+                    <label for="phone">Número de celular</label>
+                    <input type="text" id="phone" placeholder="0981123456" maxlength="10" value="{{.phone}}">
+                    <div class="field-error" id="phone-error"></div>
+                </div>
+                <div class="actions">
+                    <button class="button primary" onclick="requestPin()">Continuar</button>
+                    <button class="button secondary" onclick="processPayment('cancel')">🚫 Cancelar</button>
+                </div>
+            </div>
+            
+            <div class="step" id="step-pin">
+                <p class="hint">Enviamos una notificación al <strong id="phone-label"></strong>. Ingresá el PIN de tu billetera Zimple para confirmar el pago.</p>
+                <div class="form-group">
+                    <label for="pin">PIN</label>
+                    <input type="password" id="pin" placeholder="••••" maxlength="4">
+                    <div class="field-error" id="pin-error"></div>
+                </div>
+                <p class="hint">PIN de prueba: <code>0000</code> PIN incorrecto (55), <code>5151</code> saldo insuficiente (51), cualquier otro aprueba.</p>
+                <div class="actions">
+                    <button class="button primary" onclick="processPayment('success')">✅ Confirmar Pago (₲ {{.data.Amount}})</button>
+                    <button class="button secondary" onclick="processPayment('cancel')">🚫 Cancelar</button>
+                </div>
+            </div>
+        </div>
+    </div>
+
+    <script>
+        const fieldErrors = {
+            InvalidPhoneNumberError: 'phone',
+            InvalidPinError: 'pin'
+        };
+
+        // Dentro del iframe de bancard-checkout.js la redirección la hace la página del comercio
+        function redirectTo(url) {
+            if (window.parent !== window) {
+                window.parent.postMessage({
+                    source: 'bancard-emulator',
+                    action: 'redirect',
+                    url: new URL(url, window.location.href).href
+                }, '*');
+                return;
+            }
+            window.location.href = url;
+        }
+
+        function showFieldError(field, message) {
+            document.getElementById(field).classList.add('invalid');
+            document.getElementById(field + '-error').textContent = message;
+        }
+
+        function clearErrors() {
+            Object.values(fieldErrors).forEach(field => {
+                document.getElementById(field).classList.remove('invalid');
+                document.getElementById(field + '-error').textContent = '';
+            });
+            document.getElementById('form-alert').style.display = 'none';
+        }
+
+        function showStep(step) {
+            document.querySelectorAll('.step').forEach(element => element.classList.remove('active'));
+            document.getElementById(step).classList.add('active');
+        }
+
+        function requestPin() {
+            clearErrors();
+            const phone = document.getElementById('phone').value.replace(/\D/g, '');
+            if (!/^09[6-9]\d{7}$/.test(phone)) {
+                showFieldError('phone', 'Número de celular inválido, debe tener el formato 09XXXXXXXX');
+                return;
+            }
+            document.getElementById('phone-label').textContent = phone;
+            showStep('step-pin');
+        }
+
+        function processPayment(result) {
+            const request = { method: 'POST' };
+            clearErrors();
+
+            if (result === 'success') {
+                const pin = document.getElementById('pin').value;
+                if (!/^\d{4}$/.test(pin)) {
+                    showFieldError('pin', 'El PIN debe tener 4 dígitos');
+                    return;
+                }
+                request.headers = { 'Content-Type': 'application/json' };
+                request.body = JSON.stringify({
+                    phone: document.getElementById('phone').value.replace(/\D/g, ''),
+                    pin: pin
+                });
+            }
+
+            fetch('/emulator/bancard/{{.data.ProcessID}}?result=' + result, request)
+            .then(response => response.json())
+            .then(data => {
+                if (data.redirect_url) {
+                    redirectTo(data.redirect_url);
+                    return;
+                }
+                const error = data.messages ? data.messages[0] : { key: '', dsc: data.message };
+                if (error.key === 'InvalidPhoneNumberError') {
+                    showStep('step-phone');
+                }
+                if (fieldErrors[error.key]) {
+                    showFieldError(fieldErrors[error.key], error.dsc);
+                } else {
+                    const alertBox = document.getElementById('form-alert');
+                    alertBox.textContent = error.dsc;
+                    alertBox.style.display = 'block';
+                }
+            });
+        }
+    </script>
+</body>
+</html>`
+
+// Template para el QR de cobro: muestra el contenido del QR y simula el escaneo desde una billetera
+const bancardQRHTML = `<!DOCTYPE html>
+<html>
+<head>
+    <title>Bancard - Pago QR</title>
+    <meta charset="utf-8">
+    <style>
+        body { font-family: Arial, sans-serif; margin: 0; background: linear-gradient(135deg, #1e3c72 0%, #2a5298 100%); min-height: 100vh; }
+        .container { max-width: 500px; margin: 0 auto; padding: 40px 20px; }
+        .checkout-card { background: white; border-radius: 12px; padding: 30px; box-shadow: 0 10px 30px rgba(0,0,0,0.2); }
+        .logo { text-align: center; margin-bottom: 30px; }
+        .logo h1 { color: #1e3c72; margin: 0; font-size: 28px; }
+        .order-info { background: #f8f9fa; padding: 20px; border-radius: 8px; margin-bottom: 30px; }
+        .qr-data { font-family: monospace; word-break: break-all; background: #fff; border: 2px dashed #1e3c72; padding: 20px; border-radius: 8px; text-align: center; }
+        .form-group { margin-bottom: 15px; }
+        .form-group label { display: block; margin-bottom: 5px; font-weight: bold; color: #333; }
+        .form-group select { width: 100%; padding: 12px; border: 1px solid #ddd; border-radius: 6px; font-size: 16px; }
+        .actions { text-align: center; margin-top: 30px; }
+        .button { display: inline-block; padding: 15px 30px; margin: 10px; border: none; border-radius: 8px; font-size: 16px; cursor: pointer; text-decoration: none; font-weight: bold; }
+        .primary { background: #1e3c72; color: white; }
+        .error { background: #dc3545; color: white; }
+        .primary:hover { background: #2a5298; }
+        .status { text-align: center; font-size: 18px; font-weight: bold; margin: 20px 0; }
+        .hint { color: #6c757d; font-size: 14px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="checkout-card">
+            <div class="logo">
+                <h1>🔳 Pago QR</h1>
+                <p>Bancard - Comercio {{.payment.CommerceCode}} / Sucursal {{.payment.BranchCode}}</p>
+            </div>
+            
+            <div class="order-info">
+                <h3>📋 Información del Cobro</h3>
+                <p><strong>Hook alias:</strong> {{.qr.HookAlias}}</p>
+                <p><strong>Monto:</strong> ₲ {{.qr.Amount}}</p>
+                {{if .qr.Description}}<p><strong>Descripción:</strong> {{.qr.Description}}</p>{{end}}
+            </div>
+            
+            <div class="qr-data">{{.qr.QRData}}</div>
+            <p class="hint">Contenido del QR. Se puede pagar desde: {{range $i, $client := .clients}}{{if $i}}, {{end}}{{$client.Name}}{{end}}.</p>
+            
+            <div class="status" id="status">Estado: {{.payment.Status}}</div>
+            
+            {{if eq .payment.Status "pending"}}
+            <div id="scan">
+                <div class="form-group">
+                    <label for="client">Billetera que escanea</label>
+                    <select id="client">
+                        {{range .clients}}<option value="{{.Name}}">{{.Name}}</option>{{end}}
+                    </select>
+                </div>
+                <div class="form-group">
+                    <label for="response-code">Código de rechazo a simular</label>
+                    <select id="response-code">
+                        {{range .response_codes}}<option value="{{.Code}}"{{if eq .Code "05"}} selected{{end}}>{{.Code}} - {{.Description}}</option>{{end}}
+                    </select>
+                </div>
+                <div class="actions">
+                    <button class="button primary" onclick="scan('success')">✅ Simular Pago</button>
+                    <button class="button error" onclick="scan('error')">❌ Simular Rechazo</button>
+                </div>
+            </div>
+            {{end}}
+        </div>
+    </div>
+
+    <script>
+        function scan(result) {
+            const params = new URLSearchParams({
+                result: result,
+                client: document.getElementById('client').value
+            });
+            if (result === 'error') {
+                params.set('response_code', document.getElementById('response-code').value);
+            }
+
+            fetch('/emulator/bancard/qr/{{.qr.HookAlias}}/scan?' + params.toString(), { method: 'POST' })
+            .then(response => response.json())
+            .then(data => {
+                if (data.payment) {
+                    const payment = data.payment;
+                    document.getElementById('status').textContent = 'Estado: ' + payment.status +
+                        (payment.response_description ? ' (' + payment.response_code + ' - ' + payment.response_description + ')' : '');
+                    document.getElementById('scan').style.display = 'none';
+                    return;
+                }
+                const error = data.messages ? data.messages[0] : { dsc: data.message };
+                document.getElementById('status').textContent = error.dsc;
+            });
         }
     </script>
 </body>
@@ -177,6 +769,16 @@ const bancardResultHTML = `<!DOCTYPE html>
         <div class="message" style="color: #ffc107;">Pago Cancelado</div>
         <p>La transacción fue cancelada por el usuario.</p>
         {{end}}
+        {{if .message}}<p>{{.message}}</p>{{end}}
+        
+        {{with .response_code}}
+        <div class="details">
+            <h4>Detalle del Rechazo</h4>
+            <p><strong>Código de respuesta:</strong> {{.Code}}</p>
+            {{if .ExtendedDescription}}<p>{{.ExtendedDescription}}</p>{{end}}
+            {{if .Retryable}}<p>Puede reintentar el pago en unos minutos.</p>{{else}}<p>No reintente con esta tarjeta, utilice otro medio de pago.</p>{{end}}
+        </div>
+        {{end}}
         
         {{if .transaction_id}}
         <div class="details">
@@ -199,7 +801,10 @@ const bancardResultHTML = `<!DOCTYPE html>
     </div>
 
     <script>
-        document.getElementById('current-date').textContent = new Date().toLocaleString('es-PY');
+        const currentDate = document.getElementById('current-date');
+        if (currentDate) {
+            currentDate.textContent = new Date().toLocaleString('es-PY');
+        }
     </script>
 </body>
 </html>`
@@ -252,11 +857,84 @@ const bancardDocsHTML = `<!DOCTYPE html>
             <p><strong>Respuesta:</strong> HTML con formulario de pago</p>
         </div>
         
+        <div class="route">
+            <span class="method">GET</span>
+            <div class="path">/checkout/javascript/dist/bancard-checkout-4.0.0.js</div>
+            <p><strong>Descripción:</strong> Script compatible con <code>Bancard.Checkout.createForm(container, processId, styles)</code> y <code>Bancard.Cards.createForm</code></p>
+            <p><strong>Respuesta:</strong> JavaScript</p>
+        </div>
+        
+        <div class="route">
+            <span class="method">POST</span>
+            <div class="path">/vpos/api/0.3/preauthorizations/confirm</div>
+            <p><strong>Descripción:</strong> Capturar (total o parcialmente) una preautorización creada con <code>preauthorization: "S"</code></p>
+            <p><strong>Respuesta:</strong> JSON con la confirmación del pago</p>
+        </div>
+        
+        <div class="route">
+            <span class="method">POST</span>
+            <div class="path">/vpos/api/0.3/preauthorizations/rollback</div>
+            <p><strong>Descripción:</strong> Liberar los fondos retenidos de una preautorización</p>
+            <p><strong>Respuesta:</strong> JSON con status y mensajes</p>
+        </div>
+        
+        <div class="route">
+            <span class="method">POST</span>
+            <div class="path">/vpos/api/0.3/cards/new</div>
+            <p><strong>Descripción:</strong> Iniciar el catastro de una tarjeta (iframe en <code>/bancard/cards/new/:process_id</code>)</p>
+            <p><strong>Respuesta:</strong> JSON con process_id</p>
+        </div>
+        
+        <div class="route">
+            <span class="method">POST</span> <span class="method">DELETE</span>
+            <div class="path">/vpos/api/0.3/users/:user_id/cards</div>
+            <p><strong>Descripción:</strong> Listar (POST) o eliminar (DELETE) las tarjetas catastradas de un usuario</p>
+            <p><strong>Respuesta:</strong> JSON con las tarjetas y su alias_token</p>
+        </div>
+        
+        <div class="route">
+            <span class="method">POST</span>
+            <div class="path">/vpos/api/0.3/charge</div>
+            <p><strong>Descripción:</strong> Cobrar con el alias_token de una tarjeta catastrada</p>
+            <p><strong>Respuesta:</strong> JSON con la confirmación del pago</p>
+        </div>
+        
+        <div class="route">
+            <span class="method">POST</span>
+            <div class="path">/external-commerce/api/0.1/commerces/:commerce_code/branches/:branch_code/selling/generate-qr-express</div>
+            <p><strong>Descripción:</strong> Generar un QR de cobro (autenticación Basic <code>apps/public_key</code> : <code>private_key</code>)</p>
+            <p><strong>Respuesta:</strong> JSON con hook_alias, qr_data y la URL de la página del QR</p>
+        </div>
+        
+        <div class="route">
+            <span class="method">GET</span> <span class="method">DELETE</span>
+            <div class="path">/external-commerce/api/0.1/commerces/:commerce_code/branches/:branch_code/selling/payments/[revert/]:hook_alias</div>
+            <p><strong>Descripción:</strong> Consultar (GET) o revertir (DELETE en <code>payments/revert</code>) un pago QR</p>
+            <p><strong>Respuesta:</strong> JSON con el estado del pago</p>
+        </div>
+        
+        <div class="route">
+            <span class="method">POST</span>
+            <div class="path">/emulator/bancard/qr/:hook_alias/scan?result=success|error</div>
+            <p><strong>Descripción:</strong> Simular el escaneo del QR desde una billetera (opcional <code>response_code</code> y <code>client</code>)</p>
+            <p><strong>Respuesta:</strong> JSON con el pago y la notificación enviada al comercio</p>
+        </div>
+        
+        {{if .plugin.TestCards}}
+        <h2>🃏 Tarjetas de Prueba</h2>
+        <div class="route">
+            <p>Cualquier otra tarjeta que pase la validación (Luhn, vencimiento y CVV) se aprueba.</p>
+            {{range .plugin.TestCards}}
+            <p><code>{{.Number}}</code> → <strong>{{.ResponseCode}}</strong> {{.Description}}{{if .Timeout}} (timeout, la transacción queda pendiente){{end}}</p>
+            {{end}}
+        </div>
+        {{end}}
+        
         <h2>🧪 Ejemplo de Uso</h2>
         <div class="route">
             <p><strong>1. Crear transacción:</strong></p>
             <div class="path">POST http://localhost:{{.plugin.Port}}/vpos/api/0.3/single_buy</div>
-            <p>Payload: <code>{"public_key": "key", "operation": {"token": "token", "amount": "100000", "shop_process_id": "123"}}</code></p>
+            <p>Payload: <code>{"public_key": "pk_test_bancard", "operation": {"token": "md5(private_key + shop_process_id + amount + currency)", "amount": "100000.00", "currency": "PYG", "shop_process_id": "123"}}</code></p>
             
             <p><strong>2. Redirigir al checkout:</strong></p>
             <div class="path">GET http://localhost:{{.plugin.Port}}/bancard/checkout/{process_id}</div>
@@ -267,3 +945,78 @@ const bancardDocsHTML = `<!DOCTYPE html>
     </div>
 </body>
 </html>`
+
+// Script compatible con bancard-checkout.js: monta el checkout del emulador en un iframe
+// y redirige la página del comercio cuando el iframe informa el resultado
+const bancardCheckoutJS = `(function (window) {
+    'use strict';
+
+    var script = document.currentScript;
+    var origin = script ? new URL(script.src).origin : window.location.origin;
+    var mounted = {};
+
+    function mount(path, divId, processId, options) {
+        var container = document.getElementById(divId);
+        if (!container) {
+            throw new Error('Bancard: no existe el elemento con id "' + divId + '"');
+        }
+        if (!processId) {
+            throw new Error('Bancard: processId es obligatorio');
+        }
+
+        // El tercer parámetro puede ser el objeto de estilos o { styles: {...} }
+        var styles = (options && options.styles) || options || {};
+
+        var iframe = document.createElement('iframe');
+        iframe.src = origin + path + encodeURIComponent(processId) +
+            '?embedded=1&styles=' + encodeURIComponent(JSON.stringify(styles));
+        iframe.style.width = '100%';
+        iframe.style.minHeight = '720px';
+        iframe.style.border = '0';
+        iframe.setAttribute('allowtransparency', 'true');
+
+        destroy(divId);
+        container.appendChild(iframe);
+        mounted[divId] = iframe;
+        return iframe;
+    }
+
+    function destroy(divId) {
+        var iframe = mounted[divId];
+        if (iframe && iframe.parentNode) {
+            iframe.parentNode.removeChild(iframe);
+        }
+        delete mounted[divId];
+    }
+
+    // El iframe informa la URL de retorno (return_url con ?status=...) y la página del comercio navega
+    window.addEventListener('message', function (event) {
+        if (event.origin !== origin || !event.data || event.data.source !== 'bancard-emulator') {
+            return;
+        }
+        if (event.data.action === 'redirect' && event.data.url) {
+            window.location.href = event.data.url;
+        }
+    });
+
+    window.Bancard = window.Bancard || {};
+    window.Bancard.Checkout = {
+        createForm: function (divId, processId, options) {
+            return mount('/bancard/checkout/', divId, processId, options);
+        },
+        destroyForm: destroy
+    };
+    window.Bancard.Zimple = {
+        createForm: function (divId, processId, options) {
+            return mount('/bancard/checkout/', divId, processId, options);
+        },
+        destroyForm: destroy
+    };
+    window.Bancard.Cards = {
+        createForm: function (divId, processId, options) {
+            return mount('/bancard/cards/new/', divId, processId, options);
+        },
+        destroyForm: destroy
+    };
+})(window);
+`
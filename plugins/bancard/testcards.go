@@ -0,0 +1,117 @@
+package bancard
+
+import (
+	"context"
+	"net/http"
+	"payment-emulator/internal/clock"
+	"payment-emulator/internal/plugins"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeoutDelay es la espera por defecto de una tarjeta de prueba con timeout
+const TimeoutDelay = 3 * time.Second
+
+// testCard devuelve la tarjeta de prueba configurada para el número, o nil si no es una tarjeta mágica
+func (p *BancardPlugin) testCard(number string) *plugins.TestCard {
+	card, ok := p.config.FindTestCard(digitsOnly(number))
+	if !ok {
+		return nil
+	}
+	return card
+}
+
+// simulateTestCard aplica la demora de la tarjeta de prueba. Si la tarjeta simula un timeout, o si
+// el cliente abandona la petición durante la espera, devuelve el error que recibe el comercio
+// cuando el procesador no responde.
+func simulateTestCard(ctx context.Context, card *plugins.TestCard) *BancardError {
+	delay := card.Delay
+	if delay == 0 && card.Timeout {
+		delay = TimeoutDelay
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return timeoutError()
+	case <-timer.C:
+	}
+
+	if card.Timeout {
+		return timeoutError()
+	}
+	return nil
+}
+
+func timeoutError() *BancardError {
+	return newBancardError(http.StatusGatewayTimeout, ErrKeyTimeout, "Tiempo de espera agotado con el procesador, la transacción no fue procesada")
+}
+
+// isApproved indica si la tarjeta de prueba aprueba el pago
+func isApproved(card *plugins.TestCard) bool {
+	return card.ResponseCode == "" || card.ResponseCode == "00"
+}
+
+// validateCard valida número (Luhn), vencimiento (MM/AA no vencido) y CVV como el iframe de Bancard.
+// Un cvv vacío no se valida (catastro de tarjetas).
+func validateCard(number, expiration, cvv string, requireCvv bool) *BancardError {
+	number = digitsOnly(number)
+	if len(number) < 13 || len(number) > 19 || !luhnValid(number) {
+		return newBancardError(http.StatusBadRequest, ErrKeyInvalidCardNumber, "Número de tarjeta inválido")
+	}
+
+	if !validExpiration(expiration, clock.Now()) {
+		return newBancardError(http.StatusBadRequest, ErrKeyInvalidExpiration, "Fecha de vencimiento inválida")
+	}
+
+	if requireCvv {
+		cvvLength := 3
+		if cardBrand(number) == BrandAmex {
+			cvvLength = 4
+		}
+		if len(cvv) != cvvLength || digitsOnly(cvv) != cvv {
+			return newBancardError(http.StatusBadRequest, ErrKeyInvalidCvv, "Código de seguridad inválido")
+		}
+	}
+
+	return nil
+}
+
+// luhnValid verifica el dígito verificador del número de tarjeta
+func luhnValid(number string) bool {
+	sum := 0
+	for i := 0; i < len(number); i++ {
+		digit := int(number[len(number)-1-i] - '0')
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
+
+// validExpiration valida un vencimiento MM/AA que no haya pasado
+func validExpiration(expiration string, now time.Time) bool {
+	parts := strings.Split(strings.TrimSpace(expiration), "/")
+	if len(parts) != 2 {
+		return false
+	}
+
+	month, err := strconv.Atoi(parts[0])
+	if err != nil || month < 1 || month > 12 {
+		return false
+	}
+	year, err := strconv.Atoi(parts[1])
+	if err != nil || len(parts[1]) != 2 {
+		return false
+	}
+	year += 2000
+
+	// La tarjeta vence al final del mes indicado
+	return now.Before(time.Date(year, time.Month(month)+1, 1, 0, 0, 0, 0, time.UTC))
+}
@@ -0,0 +1,144 @@
+package bancard
+
+import (
+	"crypto/md5"
+	"fmt"
+	"net/http"
+	"payment-emulator/internal/plugins"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// md5Hex devuelve el hash MD5 en hexadecimal de la concatenación de las partes
+func md5Hex(parts ...string) string {
+	data := ""
+	for _, part := range parts {
+		data += part
+	}
+	return fmt.Sprintf("%x", md5.Sum([]byte(data)))
+}
+
+// singleBuyToken es el token esperado en single_buy:
+// md5(private_key + shop_process_id + amount + currency)
+func singleBuyToken(privateKey, shopProcessID, amount, currency string) string {
+	return md5Hex(privateKey, shopProcessID, amount, currency)
+}
+
+// getConfirmationToken es el token esperado al consultar una confirmación:
+// md5(private_key + shop_process_id + "get_confirmation")
+func getConfirmationToken(privateKey, shopProcessID string) string {
+	return md5Hex(privateKey, shopProcessID, "get_confirmation")
+}
+
+// rollbackToken es el token esperado en rollback:
+// md5(private_key + shop_process_id + "rollback" + "0.00")
+func rollbackToken(privateKey, shopProcessID string) string {
+	return md5Hex(privateKey, shopProcessID, "rollback", "0.00")
+}
+
+// refundToken es el token esperado en refund:
+// md5(private_key + shop_process_id + "refund" + amount), con amount vacío para un reembolso total
+func refundToken(privateKey, shopProcessID, amount string) string {
+	return md5Hex(privateKey, shopProcessID, "refund", amount)
+}
+
+// preauthorizationConfirmToken es el token esperado en preauthorizations/confirm:
+// md5(private_key + shop_process_id + "pre-authorization-confirm")
+func preauthorizationConfirmToken(privateKey, shopProcessID string) string {
+	return md5Hex(privateKey, shopProcessID, "pre-authorization-confirm")
+}
+
+// preauthorizationRollbackToken es el token esperado en preauthorizations/rollback:
+// md5(private_key + shop_process_id + "pre-authorization-rollback")
+func preauthorizationRollbackToken(privateKey, shopProcessID string) string {
+	return md5Hex(privateKey, shopProcessID, "pre-authorization-rollback")
+}
+
+// newCardToken es el token esperado en cards/new:
+// md5(private_key + card_id + user_id + "request_new_card")
+func newCardToken(privateKey, cardID, userID string) string {
+	return md5Hex(privateKey, cardID, userID, "request_new_card")
+}
+
+// userCardsToken es el token esperado al listar las tarjetas de un usuario:
+// md5(private_key + user_id + "request_user_cards")
+func userCardsToken(privateKey, userID string) string {
+	return md5Hex(privateKey, userID, "request_user_cards")
+}
+
+// deleteCardToken es el token esperado al eliminar una tarjeta:
+// md5(private_key + "delete_card" + user_id + alias_token)
+func deleteCardToken(privateKey, userID, aliasToken string) string {
+	return md5Hex(privateKey, "delete_card", userID, aliasToken)
+}
+
+// chargeToken es el token esperado en charge:
+// md5(private_key + shop_process_id + "charge" + amount + currency + alias_token)
+func chargeToken(privateKey, shopProcessID, amount, currency, aliasToken string) string {
+	return md5Hex(privateKey, shopProcessID, "charge", amount, currency, aliasToken)
+}
+
+// confirmationToken firma la confirmación que Bancard envía al comercio:
+// md5(private_key + shop_process_id + "confirm" + amount + currency)
+func confirmationToken(privateKey, shopProcessID, amount, currency string) string {
+	return md5Hex(privateKey, shopProcessID, "confirm", amount, currency)
+}
+
+// findMerchant busca el comercio de la clave pública. Una clave desconocida se rechaza
+// como en producción, salvo en modo permisivo donde se devuelve nil con una advertencia.
+func (p *BancardPlugin) findMerchant(publicKey string) (*plugins.Merchant, error) {
+	merchant, ok := p.config.FindMerchant(publicKey)
+	if ok {
+		return merchant, nil
+	}
+
+	if p.config.IsLenient() {
+		fmt.Printf("Bancard: clave pública desconocida %q (aceptada en modo permisivo)\n", publicKey)
+		return nil, nil
+	}
+
+	return nil, newBancardError(http.StatusUnauthorized, ErrKeyInvalidPublicKey, "Clave pública inválida")
+}
+
+// verifyToken valida un token recibido contra el esperado para el comercio de la clave pública
+// y devuelve el comercio. En modo permisivo los tokens inválidos solo generan una advertencia.
+func (p *BancardPlugin) verifyToken(publicKey, received string, expected func(privateKey string) string) (*plugins.Merchant, error) {
+	merchant, err := p.findMerchant(publicKey)
+	if err != nil || merchant == nil {
+		return nil, err
+	}
+
+	if received == expected(merchant.PrivateKey) {
+		return merchant, nil
+	}
+
+	if p.config.IsLenient() {
+		fmt.Printf("Bancard: token inválido para %s (aceptado en modo permisivo)\n", publicKey)
+		return merchant, nil
+	}
+
+	return nil, newBancardError(http.StatusUnauthorized, ErrKeyInvalidToken, "Token inválido")
+}
+
+// verifyBasicAuth valida las credenciales de la API de pagos QR, que usa autenticación básica
+// con usuario "apps/<public_key>" y la clave privada como contraseña
+func (p *BancardPlugin) verifyBasicAuth(c *gin.Context) (*plugins.Merchant, error) {
+	user, password, ok := c.Request.BasicAuth()
+	if !ok {
+		if p.config.IsLenient() {
+			fmt.Println("Bancard: petición QR sin credenciales (aceptada en modo permisivo)")
+			return nil, nil
+		}
+		return nil, newBancardError(http.StatusUnauthorized, ErrKeyInvalidCredentials, "Credenciales requeridas (Basic apps/<public_key>:<private_key>)")
+	}
+
+	publicKey := strings.TrimPrefix(user, "apps/")
+	merchant, err := p.verifyToken(publicKey, password, func(privateKey string) string {
+		return privateKey
+	})
+	if bancardErr, ok := err.(*BancardError); ok && bancardErr.Key == ErrKeyInvalidToken {
+		return nil, newBancardError(http.StatusUnauthorized, ErrKeyInvalidCredentials, "Credenciales inválidas")
+	}
+	return merchant, err
+}
@@ -0,0 +1,36 @@
+package bancard
+
+import "testing"
+
+// Vectores armados con los valores de ejemplo de la documentación de vPOS (shop_process_id 54322,
+// amount "10330.00") y la clave privada del comercio de prueba. El hash esperado es el de la cadena
+// documentada en preimage (echo -n "<preimage>" | md5sum), no el que produce el código probado.
+func TestTokens(t *testing.T) {
+	const privateKey = "sk_test_bancard"
+
+	tests := []struct {
+		name     string
+		got      string
+		preimage string
+		want     string
+	}{
+		{"single_buy", singleBuyToken(privateKey, "54322", "10330.00", "PYG"), "sk_test_bancard5432210330.00PYG", "0ee808b5feafecf4015006bfab323753"},
+		{"single_buy/confirmations", getConfirmationToken(privateKey, "54322"), "sk_test_bancard54322get_confirmation", "1e64f173bf8eab85d93cf24c992f2bd4"},
+		{"single_buy/rollback", rollbackToken(privateKey, "54322"), "sk_test_bancard54322rollback0.00", "f14a60c3af7e26c950e03c5b15b7bff6"},
+		{"refund parcial", refundToken(privateKey, "54322", "5000.00"), "sk_test_bancard54322refund5000.00", "2dfcb1a76db9ca17f7abf7421a81cf29"},
+		{"refund total", refundToken(privateKey, "54322", ""), "sk_test_bancard54322refund", "7076d23122a56ea6a37b313955f19d00"},
+		{"preauthorizations/confirm", preauthorizationConfirmToken(privateKey, "54322"), "sk_test_bancard54322pre-authorization-confirm", "533279ce4ec404ebfd7afeaf210c948b"},
+		{"preauthorizations/rollback", preauthorizationRollbackToken(privateKey, "54322"), "sk_test_bancard54322pre-authorization-rollback", "8b3000372cff372bb0b7808a8f3f1534"},
+		{"cards/new", newCardToken(privateKey, "55", "12"), "sk_test_bancard5512request_new_card", "ab3a8c18597f37457168725839e1c8e9"},
+		{"users/:user_id/cards", userCardsToken(privateKey, "12"), "sk_test_bancard12request_user_cards", "7dbd4a4be67bffbd967b88baa5e9771e"},
+		{"delete card", deleteCardToken(privateKey, "12", "c8a1d8ef"), "sk_test_bancarddelete_card12c8a1d8ef", "b64d2817b3ca97c75b5e7994985d96cf"},
+		{"charge", chargeToken(privateKey, "54322", "10330.00", "PYG", "c8a1d8ef"), "sk_test_bancard54322charge10330.00PYGc8a1d8ef", "6cc62b811844823d9ff8defbda229abb"},
+		{"confirmación al comercio", confirmationToken(privateKey, "54322", "10330.00", "PYG"), "sk_test_bancard54322confirm10330.00PYG", "bb244a78a1aeec733e27bd6d95163bea"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %s, want md5(%q) = %s", tt.name, tt.got, tt.preimage, tt.want)
+		}
+	}
+}
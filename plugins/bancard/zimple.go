@@ -0,0 +1,57 @@
+package bancard
+
+import (
+	"net/http"
+	"payment-emulator/internal/store"
+	"regexp"
+)
+
+// zimplePhonePattern valida un celular paraguayo (09XXXXXXXX)
+var zimplePhonePattern = regexp.MustCompile(`^09[6-9][0-9]{7}$`)
+
+// PIN de prueba de Zimple: fuerzan un rechazo, cualquier otro PIN de 4 dígitos aprueba
+const (
+	ZimplePinIncorrect = "0000"
+	ZimplePinNoBalance = "5151"
+	zimplePinLength    = 4
+)
+
+// isZimple indica si la transacción se paga con la billetera Zimple
+func isZimple(tx *store.Transaction) bool {
+	return tx.Meta(MetaZimple) == ZimpleEnabled
+}
+
+// zimplePhone obtiene el celular enviado en additional_data de un single_buy con zimple
+func zimplePhone(operation BancardOperation) string {
+	phone, _ := operation.AdditionalData.(string)
+	return digitsOnly(phone)
+}
+
+// validateZimplePhone valida el número de celular de la billetera
+func validateZimplePhone(phone string) *BancardError {
+	if !zimplePhonePattern.MatchString(digitsOnly(phone)) {
+		return newBancardError(http.StatusBadRequest, ErrKeyInvalidPhone, "Número de celular inválido, debe tener el formato 09XXXXXXXX")
+	}
+	return nil
+}
+
+// simulateZimple valida celular y PIN del paso de confirmación de Zimple y devuelve el código
+// de rechazo de los PIN de prueba, o nil si el pago se aprueba
+func simulateZimple(phone, pin string) (*ResponseCode, *BancardError) {
+	if err := validateZimplePhone(phone); err != nil {
+		return nil, err
+	}
+	if len(pin) != zimplePinLength || digitsOnly(pin) != pin {
+		return nil, newBancardError(http.StatusBadRequest, ErrKeyInvalidPin, "El PIN debe tener 4 dígitos")
+	}
+
+	switch pin {
+	case ZimplePinIncorrect:
+		decline := responseCodeFor("55", "")
+		return &decline, nil
+	case ZimplePinNoBalance:
+		decline := responseCodeFor("51", "Saldo insuficiente en la billetera")
+		return &decline, nil
+	}
+	return nil, nil
+}
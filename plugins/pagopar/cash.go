@@ -0,0 +1,171 @@
+package pagopar
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"net/http"
+	"payment-emulator/internal/clock"
+	"payment-emulator/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cashNetworks son las formas de pago que se abonan en efectivo en una boca de cobranza
+var cashNetworks = map[string]bool{
+	PaymentMethodAquiPago:    true,
+	PaymentMethodPagoExpress: true,
+	PaymentMethodInfonet:     true,
+	PaymentMethodWepa:        true,
+}
+
+// isCashNetwork indica si la forma de pago es una red de cobranza
+func isCashNetwork(formaPago string) bool {
+	return cashNetworks[formaPago]
+}
+
+// paysAtCashNetwork indica si el pedido se pagará en una red de cobranza con la forma de pago
+// elegida en el checkout (o, si no se eligió, la registrada en el pedido)
+func (p *PagoparPlugin) paysAtCashNetwork(hash, formaPago string) bool {
+	tx, err := p.findOrder(hash)
+	if err != nil {
+		return false
+	}
+	return isCashNetwork(firstNonEmpty(formaPago, tx.Meta(MetaFormaPago), DefaultFormaPago))
+}
+
+// setVoucherMeta emite el código de pago si la forma de pago del pedido es una red de cobranza.
+// Se conserva el código ya emitido mientras el comprador no cambie de red.
+func setVoucherMeta(tx *store.Transaction) error {
+	formaPago := tx.Meta(MetaFormaPago)
+	if !isCashNetwork(formaPago) {
+		return nil
+	}
+
+	if tx.Meta(MetaCodigoPago) == "" || tx.Meta(MetaRedCodigoPago) != formaPago {
+		tx.SetMeta(MetaCodigoPago, generateCodigoPago())
+		tx.SetMeta(MetaRedCodigoPago, formaPago)
+	}
+	tx.SetMeta(MetaUltimoError, "")
+	return nil
+}
+
+// hasPendingVoucher indica si el pedido espera el pago en efectivo de un código de cobranza
+func hasPendingVoucher(tx *store.Transaction) bool {
+	return tx.Status == store.StatusPending && tx.Meta(MetaCodigoPago) != "" && isCashNetwork(tx.Meta(MetaFormaPago))
+}
+
+// buildVoucher arma los datos del código de pago del pedido
+func buildVoucher(tx *store.Transaction) PagoparCashVoucher {
+	redCobranza := ""
+	if method, ok := findPaymentMethod(tx.Meta(MetaFormaPago)); ok {
+		redCobranza = method.Titulo
+	}
+
+	return PagoparCashVoucher{
+		CodigoPago:      tx.Meta(MetaCodigoPago),
+		RedCobranza:     redCobranza,
+		FormaPago:       tx.Meta(MetaFormaPago),
+		HashPedido:      tx.ID,
+		NumeroPedido:    tx.Reference,
+		Monto:           store.FormatAmount(tx.Amount),
+		FechaMaximaPago: fechaMaximaPago(tx).Format(DateFormat),
+	}
+}
+
+// findVoucher busca el pedido pendiente de un código de pago (también acepta el hash del pedido)
+func (p *PagoparPlugin) findVoucher(codigo string) (*store.Transaction, error) {
+	for _, tx := range p.store.List(GatewayName) {
+		if tx.Meta(MetaCodigoPago) == codigo {
+			return p.expireIfDue(tx, clock.Now()), nil
+		}
+	}
+
+	tx, err := p.findOrder(codigo)
+	if err != nil || tx.Meta(MetaCodigoPago) == "" {
+		return nil, newPagoparError(http.StatusNotFound, "No existe el código de pago "+codigo)
+	}
+	return tx, nil
+}
+
+// handleVoucher muestra al comprador el código para pagar en la boca de cobranza
+func (p *PagoparPlugin) handleVoucher(c *gin.Context) {
+	hash := c.Param("hash")
+
+	tx, err := p.findOrder(hash)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "pagopar_result.html", gin.H{
+			"hash":    hash,
+			"result":  PaymentStatusError,
+			"message": orderNotFoundError().Message,
+		})
+		return
+	}
+
+	if !hasPendingVoucher(tx) {
+		message := noVoucherError().Message
+		if !pagoparLifecycle.Allows(tx.Status, store.StatusPaid) {
+			message = closedOrderError(tx.Status).Message
+		}
+		c.HTML(http.StatusConflict, "pagopar_result.html", gin.H{
+			"hash":    hash,
+			"result":  orderResult(tx.Status),
+			"message": message,
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "pagopar_voucher.html", gin.H{
+		"voucher": buildVoucher(tx),
+	})
+}
+
+// handleEmulatorListVouchers lista los códigos de pago de cobranza pendientes
+func (p *PagoparPlugin) handleEmulatorListVouchers(c *gin.Context) {
+	vouchers := make([]PagoparCashVoucher, 0)
+	now := clock.Now()
+	for _, tx := range p.store.List(GatewayName) {
+		if tx = p.expireIfDue(tx, now); hasPendingVoucher(tx) {
+			vouchers = append(vouchers, buildVoucher(tx))
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"vouchers": vouchers,
+		"total":    len(vouchers),
+	})
+}
+
+// handleEmulatorPayVoucher simula que el comprador paga el código en la boca de cobranza.
+// El pedido pasa a pagado y se notifica al comercio.
+func (p *PagoparPlugin) handleEmulatorPayVoucher(c *gin.Context) {
+	tx, err := p.findVoucher(c.Param("codigo"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	// Un pedido cerrado responde el error de su estado al intentar la transición
+	if !hasPendingVoucher(tx) && pagoparLifecycle.Allows(tx.Status, store.StatusPaid) {
+		respondError(c, noVoucherError())
+		return
+	}
+
+	voucher := buildVoucher(tx)
+	tx, err = p.transition(tx.ID, store.StatusPaid, fmt.Sprintf("Pago en boca de cobranza (%s, código %s)", voucher.RedCobranza, voucher.CodigoPago), setPaidMeta)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Pago en boca de cobranza simulado",
+		"voucher":          voucher,
+		"webhook_data":     p.buildOrderStatus(tx),
+		"webhook_delivery": p.notifyMerchant(tx, WebhookEventPayment),
+		"hash":             tx.ID,
+	})
+}
+
+// generateCodigoPago genera el número de referencia que el comprador presenta en la boca de cobranza
+func generateCodigoPago() string {
+	return fmt.Sprintf("%08d", mathrand.Intn(100000000))
+}
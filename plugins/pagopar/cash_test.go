@@ -0,0 +1,59 @@
+package pagopar
+
+import (
+	"net/http"
+	"payment-emulator/internal/store"
+	"testing"
+)
+
+// voucherList es la respuesta de GET /emulator/cash
+type voucherList struct {
+	Vouchers []PagoparCashVoucher `json:"vouchers"`
+	Total    int                  `json:"total"`
+}
+
+func TestCashVoucherIsPaidAtCashNetwork(t *testing.T) {
+	s := newTestServer(t)
+	hash := s.createOrder(merchantA, "4467", 100000)
+
+	// Pagar con Aqui Pago en el checkout solo emite el código de pago
+	var simulated struct {
+		Voucher PagoparCashVoucher `json:"voucher"`
+	}
+	if code := s.do(http.MethodPost, "/emulator/webhook/"+hash+"?result=success&forma_pago="+PaymentMethodAquiPago, nil, &simulated); code != http.StatusOK {
+		t.Fatalf("checkout with forma_pago %s = %d, want 200", PaymentMethodAquiPago, code)
+	}
+	codigo := simulated.Voucher.CodigoPago
+	if codigo == "" || s.order(hash).Status != store.StatusPending {
+		t.Fatalf("checkout at cash network left order %s with codigo %q, want pending with codigo", s.order(hash).Status, codigo)
+	}
+
+	var pending voucherList
+	s.do(http.MethodGet, "/emulator/cash", nil, &pending)
+	if pending.Total != 1 || pending.Vouchers[0].CodigoPago != codigo || pending.Vouchers[0].HashPedido != hash || pending.Vouchers[0].Monto != "100000.00" {
+		t.Fatalf("pending vouchers = %+v, want the order's voucher for 100000", pending)
+	}
+
+	if code := s.do(http.MethodPost, "/emulator/cash/"+codigo+"/pay", nil, nil); code != http.StatusOK {
+		t.Fatalf("pay voucher %s = %d, want 200", codigo, code)
+	}
+	if tx := s.order(hash); tx.Status != store.StatusPaid {
+		t.Errorf("order is %s after paying the voucher, want %s", tx.Status, store.StatusPaid)
+	}
+
+	var paid voucherList
+	s.do(http.MethodGet, "/emulator/cash", nil, &paid)
+	if paid.Total != 0 {
+		t.Errorf("pending vouchers after payment = %d, want 0", paid.Total)
+	}
+
+	// Un código ya pagado o inexistente no se puede volver a pagar
+	var again PagoparErrorResponse
+	if code := s.do(http.MethodPost, "/emulator/cash/"+codigo+"/pay", nil, &again); code == http.StatusOK {
+		t.Errorf("paying voucher %s twice = %d, want an error", codigo, code)
+	}
+	var unknown PagoparErrorResponse
+	if code := s.do(http.MethodPost, "/emulator/cash/99999999x/pay", nil, &unknown); code != http.StatusNotFound {
+		t.Errorf("paying unknown voucher = %d %q, want 404", code, unknown.Resultado)
+	}
+}
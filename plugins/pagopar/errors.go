@@ -0,0 +1,74 @@
+package pagopar
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"payment-emulator/internal/plugins"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// PagoparError representa un error de negocio de la API de Pagopar
+type PagoparError struct {
+	Message    string
+	HTTPStatus int
+}
+
+func (e *PagoparError) Error() string {
+	return e.Message
+}
+
+// PagoparErrorResponse representa la respuesta de error de Pagopar
+type PagoparErrorResponse struct {
+	Respuesta bool   `json:"respuesta"`
+	Resultado string `json:"resultado"`
+}
+
+func newPagoparError(httpStatus int, message string) *PagoparError {
+	return &PagoparError{Message: message, HTTPStatus: httpStatus}
+}
+
+// orderNotFoundError es el error de Pagopar para un hash de pedido inexistente
+func orderNotFoundError() *PagoparError {
+	return newPagoparError(http.StatusNotFound, "No existe pedido")
+}
+
+// noVoucherError indica que el pedido sigue abierto pero no espera un pago en efectivo
+// (todavía no se emitió el código o el comprador eligió otra forma de pago)
+func noVoucherError() *PagoparError {
+	return newPagoparError(http.StatusConflict, "El pedido no tiene un código de pago de cobranza pendiente")
+}
+
+// respondError responde con el formato de error de Pagopar
+func respondError(c *gin.Context, err error) {
+	var pagoparErr *PagoparError
+	if !errors.As(err, &pagoparErr) {
+		pagoparErr = newPagoparError(http.StatusInternalServerError, err.Error())
+	}
+
+	c.JSON(pagoparErr.HTTPStatus, PagoparErrorResponse{
+		Respuesta: false,
+		Resultado: pagoparErr.Message,
+	})
+}
+
+// bindingError traduce un error de c.ShouldBindJSON a un mensaje de Pagopar sin el texto interno de gin
+func bindingError(err error) *PagoparError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) && len(validationErrs) > 0 {
+		field := validationErrs[0]
+		if field.Tag() == "min" {
+			return newPagoparError(http.StatusBadRequest, "El pedido debe tener al menos un item en "+plugins.JSONFieldPath(field.Namespace()))
+		}
+		return newPagoparError(http.StatusBadRequest, "Falta el campo obligatorio "+plugins.JSONFieldPath(field.Namespace()))
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return newPagoparError(http.StatusBadRequest, "El campo "+typeErr.Field+" tiene un formato inválido")
+	}
+
+	return newPagoparError(http.StatusBadRequest, "Datos JSON inválidos")
+}
@@ -0,0 +1,72 @@
+package pagopar
+
+import (
+	"payment-emulator/internal/clock"
+	"payment-emulator/internal/store"
+	"time"
+)
+
+// ExpirationCheckInterval es cada cuánto se revisan los pedidos vencidos. Al ajustar el reloj
+// del emulador la revisión es inmediata.
+const ExpirationCheckInterval = time.Minute
+
+// startExpirationWatcher vence periódicamente los pedidos cuya fecha_maxima_pago ya pasó
+func (p *PagoparPlugin) startExpirationWatcher() {
+	p.watcher.Do(func() {
+		clock.GetGlobalClock().OnChange(p.expireDueOrders)
+
+		go func() {
+			ticker := time.NewTicker(ExpirationCheckInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				p.expireDueOrders(clock.Now())
+			}
+		}()
+	})
+}
+
+// expireDueOrders vence todos los pedidos impagos cuya fecha_maxima_pago es anterior a now
+func (p *PagoparPlugin) expireDueOrders(now time.Time) {
+	for _, tx := range p.store.List(GatewayName) {
+		p.expireIfDue(tx, now)
+	}
+}
+
+// expireIfDue vence el pedido si superó su fecha_maxima_pago sin pagarse y notifica al comercio.
+// Devuelve el pedido con su estado actualizado.
+func (p *PagoparPlugin) expireIfDue(tx *store.Transaction, now time.Time) *store.Transaction {
+	if !pagoparLifecycle.Allows(tx.Status, store.StatusExpired) || !now.After(fechaMaximaPago(tx)) {
+		return tx
+	}
+
+	expired, err := p.store.Transition(GatewayName, tx.ID, store.StatusExpired, "Fecha máxima de pago vencida", nil)
+	if err != nil {
+		// Otra petición cerró el pedido al mismo tiempo: se devuelve su estado actual
+		if current, err := p.store.Get(GatewayName, tx.ID); err == nil {
+			return current
+		}
+		return tx
+	}
+
+	p.notifyMerchant(expired, WebhookEventExpiration)
+	return expired
+}
+
+// findOrder busca un pedido por hash, venciéndolo si su fecha_maxima_pago ya pasó
+func (p *PagoparPlugin) findOrder(hash string) (*store.Transaction, error) {
+	tx, err := p.store.Get(GatewayName, hash)
+	if err != nil {
+		return nil, orderNotFoundError()
+	}
+	return p.expireIfDue(tx, clock.Now()), nil
+}
+
+// findMerchantOrder busca un pedido del comercio de la clave pública. El pedido de otro
+// comercio se responde como inexistente, igual que un hash desconocido.
+func (p *PagoparPlugin) findMerchantOrder(publicKey, hash string) (*store.Transaction, error) {
+	tx, err := p.store.Get(GatewayName, hash)
+	if err != nil || tx.Meta(MetaPublicKey) != publicKey {
+		return nil, orderNotFoundError()
+	}
+	return p.expireIfDue(tx, clock.Now()), nil
+}
@@ -0,0 +1,47 @@
+package pagopar
+
+import (
+	"net/http"
+	"payment-emulator/internal/clock"
+	"payment-emulator/internal/store"
+	"testing"
+	"time"
+)
+
+func TestOrderExpiresAfterFechaMaximaPago(t *testing.T) {
+	s := newTestServer(t)
+	t.Cleanup(func() { clock.GetGlobalClock().Reset() })
+
+	request := orderRequest(merchantA, "5578", 100000)
+	request["fecha_maxima_pago"] = clock.Now().Add(time.Hour).Format(DateFormat)
+	var created PagoparOrderResponse
+	if code := s.do(http.MethodPost, "/api/comercios/2.0/iniciar-transaccion", request, &created); code != http.StatusOK {
+		t.Fatalf("iniciar-transaccion = %d, want 200", code)
+	}
+	withFecha := created.Resultado[0].Data
+	withDefault := s.createOrder(merchantA, "5579", 100000)
+
+	// Pasada la fecha_maxima_pago indicada el pedido expira y ya no puede pagarse
+	clock.GetGlobalClock().Advance(2 * time.Hour)
+	var status PagoparOrderStatusResponse
+	if code := s.orderStatus(merchantA, withFecha, &status); code != http.StatusOK {
+		t.Fatalf("pedidos/1.1/traer = %d, want 200", code)
+	}
+	if tx := s.order(withFecha); tx.Status != store.StatusExpired {
+		t.Errorf("order past fecha_maxima_pago is %s, want %s", tx.Status, store.StatusExpired)
+	}
+	var rejected PagoparErrorResponse
+	if code := s.do(http.MethodPost, "/emulator/webhook/"+withFecha+"?result=success", nil, &rejected); code != http.StatusConflict || rejected.Resultado != "El pedido ya expiró" {
+		t.Errorf("paying an expired order = %d %q, want 409 \"El pedido ya expiró\"", code, rejected.Resultado)
+	}
+
+	// Sin fecha_maxima_pago el pedido puede pagarse durante DefaultPaymentWindow
+	if tx := s.order(withDefault); tx.Status == store.StatusExpired {
+		t.Fatalf("order without fecha_maxima_pago expired after 2h, want it open for %s", DefaultPaymentWindow)
+	}
+	clock.GetGlobalClock().Advance(DefaultPaymentWindow)
+	var expired PagoparErrorResponse
+	if code := s.do(http.MethodPost, "/emulator/webhook/"+withDefault+"?result=success", nil, &expired); code != http.StatusConflict || expired.Resultado != "El pedido ya expiró" {
+		t.Errorf("paying an order after %s = %d %q, want 409 \"El pedido ya expiró\"", DefaultPaymentWindow, code, expired.Resultado)
+	}
+}
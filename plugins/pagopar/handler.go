@@ -1,11 +1,20 @@
 package pagopar
 
 import (
-	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"math/rand"
+	"math"
+	mathrand "math/rand"
 	"net/http"
+	"payment-emulator/internal/clock"
 	"payment-emulator/internal/plugins"
+	"payment-emulator/internal/store"
+	"payment-emulator/internal/webhook"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,6 +25,9 @@ type PagoparPlugin struct {
 	pluginType string
 	port       int
 	config     *plugins.Plugin
+	store      *store.Store
+	webhooks   *webhook.Dispatcher
+	watcher    sync.Once
 }
 
 // NewPagoparPlugin crea una nueva instancia del plugin de Pagopar
@@ -24,6 +36,8 @@ func NewPagoparPlugin(config *plugins.Plugin) plugins.PaymentPlugin {
 		name:       "Pagopar",
 		pluginType: "popup",
 		config:     config,
+		store:      store.GetGlobalStore(),
+		webhooks:   webhook.GetGlobalDispatcher(),
 	}
 }
 
@@ -42,6 +56,7 @@ func (p *PagoparPlugin) SetupRoutes(r *gin.Engine) {
 	p.setupAPIRoutes(r)
 	p.setupCheckoutRoutes(r)
 	p.setupEmulatorRoutes(r)
+	p.startExpirationWatcher()
 }
 
 // GetTemplates devuelve los templates específicos de Pagopar
@@ -85,8 +100,12 @@ func (p *PagoparPlugin) setupCheckoutRoutes(r *gin.Engine) {
 	// Step 2: Página de checkout de Pagopar
 	r.GET("/pagos/:hash", p.handleCheckout)
 
-	// Endpoint para resultado
+	// Retorno del comprador al comercio (url_resultado) y abandono del checkout (url_cancelacion)
 	r.GET("/resultado/:hash", p.handleResult)
+	r.GET("/pagos/:hash/cancelar", p.handleCancel)
+
+	// Código de pago para redes de cobranza (Aqui Pago, Pago Express, Infonet, Wepa)
+	r.GET("/pagos/:hash/comprobante", p.handleVoucher)
 }
 
 // setupEmulatorRoutes configura las rutas del emulador
@@ -96,39 +115,109 @@ func (p *PagoparPlugin) setupEmulatorRoutes(r *gin.Engine) {
 
 	// Página de resultado del pago
 	r.GET("/emulator/result", p.handleEmulatorResult)
+
+	// Simular el pago en efectivo de un código de cobranza
+	r.GET("/emulator/cash", p.handleEmulatorListVouchers)
+	r.POST("/emulator/cash/:codigo/pay", p.handleEmulatorPayVoucher)
 }
 
 // handleIniciarTransaccion maneja la creación de una nueva transacción
 func (p *PagoparPlugin) handleIniciarTransaccion(c *gin.Context) {
 	var request PagoparOrderRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, PagoparOrderResponse{
-			Respuesta: false,
-			Error:     "Datos JSON inválidos: " + err.Error(),
-		})
+		respondError(c, bindingError(err))
 		return
 	}
 
-	// Validar campos obligatorios
-	if request.Token == "" {
-		c.JSON(http.StatusBadRequest, PagoparOrderResponse{
-			Respuesta: false,
-			Error:     "Campo obligatorio faltante: token",
-		})
+	montoTotal, err := store.ParseAmount(request.MontoTotal.String())
+	if err != nil || montoTotal <= 0 {
+		respondError(c, newPagoparError(http.StatusBadRequest, "El monto total no es válido."))
 		return
 	}
 
-	if request.MontoTotal == "" {
-		c.JSON(http.StatusBadRequest, PagoparOrderResponse{
-			Respuesta: false,
-			Error:     "Campo obligatorio faltante: monto_total",
-		})
+	merchant, err := p.verifyToken(request.PublicKey, request.Token, func(privateKey string) string {
+		return orderToken(privateKey, request.IdPedidoComercio.String(), montoTotal)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := p.checkIdPedido(request.PublicKey, request.IdPedidoComercio.String()); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if merchant != nil && !merchant.AllowsCurrency(DefaultCurrency) {
+		respondError(c, newPagoparError(http.StatusBadRequest, "Moneda no habilitada para el comercio: "+DefaultCurrency))
 		return
 	}
 
-	// Generar respuesta de orden creada
+	tipoPedido := firstNonEmpty(request.TipoPedido, TipoPedidoVentaComercio)
+	if tipoPedido != TipoPedidoVentaComercio && tipoPedido != TipoPedidoComercioHeredado {
+		respondError(c, newPagoparError(http.StatusBadRequest, "Tipo de pedido no válido: "+tipoPedido))
+		return
+	}
+
+	fechaMaxima, err := parseFechaMaximaPago(request.FechaMaximaPago)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	formaPago := request.FormaPago.String()
+	if formaPago != "" {
+		if _, ok := findPaymentMethod(formaPago); !ok {
+			respondError(c, newPagoparError(http.StatusBadRequest, "Forma de pago inválida: "+formaPago))
+			return
+		}
+	}
+
+	items, err := parseItems(request.ComprasItems, montoTotal)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Generar y registrar la orden creada
 	hash := generateOrderHash()
 	orderNumber := generateOrderNumber()
+	comprador := request.Comprador
+
+	tx := &store.Transaction{
+		Gateway:     GatewayName,
+		ID:          hash,
+		Reference:   orderNumber,
+		Amount:      montoTotal,
+		Currency:    DefaultCurrency,
+		Description: request.DescripcionResumen,
+		Buyer: store.Buyer{
+			Name:         firstNonEmpty(comprador.Nombre, comprador.RazonSocial),
+			Email:        comprador.Email,
+			Phone:        comprador.Telefono,
+			Document:     comprador.Documento.String(),
+			DocumentType: comprador.TipoDocumento,
+		},
+		Items:     items,
+		ReturnURL: request.UrlResultado,
+		CancelURL: request.UrlCancelacion,
+	}
+	tx.SetMeta(MetaUrlRespuesta, request.UrlRespuesta)
+	tx.SetMeta(MetaPublicKey, request.PublicKey)
+	tx.SetMeta(MetaIdPedido, request.IdPedidoComercio.String())
+	tx.SetMeta(MetaTipoPedido, tipoPedido)
+	tx.SetMeta(MetaFechaMaxima, fechaMaxima.Format(DateFormat))
+	tx.SetMeta(MetaFormaPago, formaPago)
+	tx.SetMeta(MetaRuc, comprador.Ruc)
+	tx.SetMeta(MetaRazonSocial, comprador.RazonSocial)
+	tx.SetMeta(MetaCiudad, comprador.Ciudad.String())
+	tx.SetMeta(MetaDireccion, comprador.Direccion)
+	tx.SetMeta(MetaCoordenadas, comprador.Coordenadas)
+
+	if _, err := p.store.Create(tx); err != nil {
+		respondError(c, err)
+		return
+	}
 
 	response := PagoparOrderResponse{
 		Respuesta: true,
@@ -161,6 +250,11 @@ func (p *PagoparPlugin) handleGetPaymentMethods(c *gin.Context) {
 		return
 	}
 
+	if _, err := p.verifyToken(request.TokenPublico, request.Token, paymentMethodsToken); err != nil {
+		respondError(c, err)
+		return
+	}
+
 	response := PagoparPaymentMethodsResponse{
 		Respuesta: true,
 		Resultado: getPaymentMethods(),
@@ -173,24 +267,24 @@ func (p *PagoparPlugin) handleGetPaymentMethods(c *gin.Context) {
 func (p *PagoparPlugin) handleGetOrderStatus(c *gin.Context) {
 	var request PagoparOrderStatusRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, PagoparOrderStatusResponse{
-			Respuesta: false,
-		})
+		respondError(c, newPagoparError(http.StatusBadRequest, "Faltan campos requeridos: hash_pedido, token, token_publico"))
 		return
 	}
 
-	// Validar campos obligatorios
-	if request.HashPedido == "" || request.Token == "" || request.TokenPublico == "" {
-		c.JSON(http.StatusBadRequest, PagoparOrderStatusResponse{
-			Respuesta: false,
-		})
+	if _, err := p.verifyToken(request.TokenPublico, request.Token, queryToken); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	tx, err := p.findMerchantOrder(request.TokenPublico, request.HashPedido)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	status := getOrderStatus(request.HashPedido)
 	response := PagoparOrderStatusResponse{
 		Respuesta: true,
-		Resultado: []PagoparOrderStatusData{status},
+		Resultado: []PagoparOrderStatusData{p.buildOrderStatus(tx)},
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -200,33 +294,32 @@ func (p *PagoparPlugin) handleGetOrderStatus(c *gin.Context) {
 func (p *PagoparPlugin) handleGetOrderStatusLegacy(c *gin.Context) {
 	var request map[string]interface{}
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		respondError(c, newPagoparError(http.StatusBadRequest, "Datos JSON inválidos"))
 		return
 	}
 
-	hashPedido, hashOk := request["hash_pedido"].(string)
-	token, tokenOk := request["token"].(string)
+	hashPedido, _ := request["hash_pedido"].(string)
+	token, _ := request["token"].(string)
+	if hashPedido == "" || token == "" {
+		respondError(c, newPagoparError(http.StatusBadRequest, "Faltan campos requeridos: hash_pedido, token"))
+		return
+	}
 
-	if !hashOk || !tokenOk {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"respuesta": false,
-			"mensaje":   "Faltan campos requeridos: hash_pedido, token",
-		})
+	tx, err := p.findOrder(hashPedido)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"respuesta": false,
-			"mensaje":   "Token inválido",
-		})
+	// La versión legacy no envía token_publico: se valida con el comercio que creó el pedido
+	if _, err := p.verifyToken(tx.Meta(MetaPublicKey), token, queryToken); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	status := getOrderStatus(hashPedido)
-	response := gin.H{
-		"respuesta": true,
-		"resultado": []PagoparOrderStatusData{status},
+	response := PagoparOrderStatusResponse{
+		Respuesta: true,
+		Resultado: []PagoparOrderStatusData{p.buildOrderStatus(tx)},
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -235,53 +328,90 @@ func (p *PagoparPlugin) handleGetOrderStatusLegacy(c *gin.Context) {
 // handleCheckout maneja la página de checkout
 func (p *PagoparPlugin) handleCheckout(c *gin.Context) {
 	hash := c.Param("hash")
-	formaPago := c.Query("forma_pago")
+
+	tx, err := p.findOrder(hash)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "pagopar_result.html", gin.H{
+			"hash":    hash,
+			"result":  PaymentStatusError,
+			"message": "El pedido no existe",
+		})
+		return
+	}
+
+	if !pagoparLifecycle.Allows(tx.Status, store.StatusPaid) {
+		c.HTML(http.StatusConflict, "pagopar_result.html", gin.H{
+			"hash":    hash,
+			"result":  PaymentStatusError,
+			"message": closedOrderError(tx.Status).Message,
+		})
+		return
+	}
 
 	// Usar template específico de Pagopar
 	c.HTML(http.StatusOK, "pagopar_checkout.html", gin.H{
-		"hash":      hash,
-		"formaPago": formaPago,
-		"methods":   getPaymentMethods(),
+		"hash":        hash,
+		"formaPago":   firstNonEmpty(c.Query("forma_pago"), tx.Meta(MetaFormaPago)),
+		"descripcion": tx.Description,
+		"methods":     getPaymentMethods(),
+		"monto":       store.FormatAmount(tx.Amount),
+		"pedido":      tx.Reference,
 	})
 }
 
-// handleResult maneja el resultado final
+// handleResult devuelve al comprador a la url_resultado del pedido o del comercio
 func (p *PagoparPlugin) handleResult(c *gin.Context) {
-	hash := c.Param("hash")
-	// Redirigir a la aplicación principal
-	redirectURL := fmt.Sprintf("http://localhost/pr/%s", hash)
-	c.Redirect(http.StatusFound, redirectURL)
+	p.redirectToMerchant(c, c.Param("hash"), "")
+}
+
+// handleCancel devuelve al comprador que abandona el checkout a la url_cancelacion. El pedido
+// sigue pendiente hasta su fecha_maxima_pago, como en Pagopar.
+func (p *PagoparPlugin) handleCancel(c *gin.Context) {
+	p.redirectToMerchant(c, c.Param("hash"), PaymentStatusCancel)
 }
 
-// handleWebhookConfirm maneja confirmación de webhook
+// handleWebhookConfirm marca como pagado un pedido del comercio. La petición se firma como el
+// webhook de Pagopar: sha1(private_key + hash_pedido).
 func (p *PagoparPlugin) handleWebhookConfirm(c *gin.Context) {
-	var request map[string]interface{}
+	p.handleMerchantWebhook(c, store.StatusPaid, "Confirmación recibida", setPaidMeta, WebhookEventPayment)
+}
+
+// handleWebhookReversal revierte el pago de un pedido del comercio, con la misma firma que la confirmación
+func (p *PagoparPlugin) handleWebhookReversal(c *gin.Context) {
+	p.handleMerchantWebhook(c, store.StatusReversed, "Reversión recibida", nil, WebhookEventReversal)
+}
+
+// handleMerchantWebhook verifica el token del comercio, aplica la transición sobre su pedido y
+// le notifica el nuevo estado
+func (p *PagoparPlugin) handleMerchantWebhook(c *gin.Context, to store.Status, note string, fn func(tx *store.Transaction) error, event string) {
+	var request PagoparWebhookRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		respondError(c, bindingError(err))
 		return
 	}
 
-	hashPedido := request["hash_pedido"].(string)
-	confirmationData := generateWebhookData(hashPedido, PaymentStatusSuccess)
-
-	response := PagoparWebhookData{
-		Resultado: []PagoparOrderStatusData{confirmationData},
-		Respuesta: true,
+	_, err := p.verifyToken(request.TokenPublico, request.Token, func(privateKey string) string {
+		return webhookToken(privateKey, request.HashPedido)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
-}
-
-// handleWebhookReversal maneja webhook de reversal
-func (p *PagoparPlugin) handleWebhookReversal(c *gin.Context) {
-	var request map[string]interface{}
-	c.ShouldBindJSON(&request)
+	if _, err := p.findMerchantOrder(request.TokenPublico, request.HashPedido); err != nil {
+		respondError(c, err)
+		return
+	}
 
-	hashPedido := request["hash_pedido"].(string)
-	reversalData := generateWebhookData(hashPedido, PaymentStatusError)
+	tx, err := p.store.Transition(GatewayName, request.HashPedido, to, note, fn)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	p.notifyMerchant(tx, event)
 
 	response := PagoparWebhookData{
-		Resultado: []PagoparOrderStatusData{reversalData},
+		Resultado: []PagoparOrderStatusData{p.buildOrderStatus(tx)},
 		Respuesta: true,
 	}
 
@@ -293,51 +423,199 @@ func (p *PagoparPlugin) handleEmulatorWebhook(c *gin.Context) {
 	hash := c.Param("hash")
 	result := c.Query("result")
 
-	webhookData := generateWebhookData(hash, result)
+	// Forma de pago elegida en el checkout (?forma_pago=24); por defecto la del último intento o tarjeta
+	formaPago := c.Query("forma_pago")
+	if formaPago != "" {
+		if _, ok := findPaymentMethod(formaPago); !ok {
+			respondError(c, newPagoparError(http.StatusBadRequest, "Forma de pago inválida: "+formaPago))
+			return
+		}
+	}
+	withFormaPago := func(fn func(tx *store.Transaction) error) func(tx *store.Transaction) error {
+		return func(tx *store.Transaction) error {
+			tx.SetMeta(MetaFormaPago, firstNonEmpty(formaPago, tx.Meta(MetaFormaPago), DefaultFormaPago))
+			if fn == nil {
+				return nil
+			}
+			return fn(tx)
+		}
+	}
+
+	// Las redes de cobranza no se pagan en el checkout: se emite un código de pago y el pedido
+	// queda pendiente hasta que se pague en la boca de cobranza
+	if result == PaymentStatusSuccess && p.paysAtCashNetwork(hash, formaPago) {
+		result = PaymentStatusPending
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":      "Simulador de webhook",
-		"webhook_data": webhookData,
-		"hash":         hash,
-	})
+	var tx *store.Transaction
+	var err error
+
+	switch result {
+	case PaymentStatusSuccess:
+		tx, err = p.transition(hash, store.StatusPaid, "Pago simulado", withFormaPago(setPaidMeta))
+	case PaymentStatusError:
+		tx, err = p.transition(hash, store.StatusFailed, "Error simulado", withFormaPago(func(tx *store.Transaction) error {
+			tx.SetMeta(MetaUltimoError, "Transacción rechazada por la entidad emisora")
+			return nil
+		}))
+	case PaymentStatusPending:
+		tx, err = p.transition(hash, store.StatusPending, "Pago pendiente simulado", withFormaPago(setVoucherMeta))
+	case PaymentStatusCancel:
+		tx, err = p.transition(hash, store.StatusCancelled, "Cancelación simulada", nil)
+	default:
+		err = newPagoparError(http.StatusBadRequest, "Resultado de simulación inválido: "+result)
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Pagopar notifica al comercio cuando el pedido se paga
+	var delivery *webhook.Delivery
+	if tx.Status == store.StatusPaid {
+		delivery = p.notifyMerchant(tx, WebhookEventPayment)
+	}
+
+	response := gin.H{
+		"message":          "Simulador de webhook",
+		"webhook_data":     p.buildOrderStatus(tx),
+		"webhook_delivery": delivery,
+		"hash":             hash,
+	}
+	if hasPendingVoucher(tx) {
+		response["voucher"] = buildVoucher(tx)
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // handleEmulatorResult maneja la página de resultado del emulador
 func (p *PagoparPlugin) handleEmulatorResult(c *gin.Context) {
-	hash := c.Query("hash")
-	result := c.Query("result")
+	p.redirectToMerchant(c, c.Query("hash"), "")
+}
+
+// redirectToMerchant redirige al comprador a la URL de retorno del pedido con el hash reemplazado.
+// Sin URL configurada se muestra la página de resultado del emulador.
+func (p *PagoparPlugin) redirectToMerchant(c *gin.Context, hash, result string) {
+	tx, err := p.findOrder(hash)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "pagopar_result.html", gin.H{
+			"hash":    hash,
+			"result":  PaymentStatusError,
+			"message": orderNotFoundError().Message,
+		})
+		return
+	}
 
-	if result == PaymentStatusSuccess {
-		redirectURL := fmt.Sprintf("http://localhost/pr/%s", hash)
+	redirectURL := p.resultURL(tx)
+	if result == PaymentStatusCancel {
+		redirectURL = firstNonEmpty(p.cancelURL(tx), redirectURL)
+	}
+	if redirectURL != "" {
 		c.Redirect(http.StatusFound, redirectURL)
 		return
 	}
 
 	c.HTML(http.StatusOK, "pagopar_result.html", gin.H{
 		"hash":   hash,
-		"result": result,
+		"result": firstNonEmpty(result, orderResult(tx.Status)),
 	})
 }
 
+// resultURL devuelve la url_resultado del pedido, o la configurada para el comercio
+func (p *PagoparPlugin) resultURL(tx *store.Transaction) string {
+	if tx.ReturnURL != "" {
+		return expandOrderURL(tx.ReturnURL, tx.ID)
+	}
+	if merchant, ok := p.config.FindMerchant(tx.Meta(MetaPublicKey)); ok {
+		return expandOrderURL(merchant.ResultURL, tx.ID)
+	}
+	return ""
+}
+
+// cancelURL devuelve la url_cancelacion del pedido, o la configurada para el comercio
+func (p *PagoparPlugin) cancelURL(tx *store.Transaction) string {
+	if tx.CancelURL != "" {
+		return expandOrderURL(tx.CancelURL, tx.ID)
+	}
+	if merchant, ok := p.config.FindMerchant(tx.Meta(MetaPublicKey)); ok {
+		return expandOrderURL(merchant.CancelURL, tx.ID)
+	}
+	return ""
+}
+
+// expandOrderURL reemplaza el marcador ($hash) de Pagopar por el hash del pedido
+func expandOrderURL(url, hash string) string {
+	url = strings.ReplaceAll(url, HashPlaceholder, hash)
+	return strings.ReplaceAll(url, "(%24hash)", hash)
+}
+
+// orderResult traduce el estado del pedido al resultado mostrado en la página del emulador
+func orderResult(status store.Status) string {
+	switch status {
+	case store.StatusPaid:
+		return PaymentStatusSuccess
+	case store.StatusFailed:
+		return PaymentStatusError
+	case store.StatusCancelled, store.StatusExpired, store.StatusReversed:
+		return PaymentStatusCancel
+	default:
+		return PaymentStatusPending
+	}
+}
+
+// transition cambia el estado de un pedido traduciendo los errores al formato de Pagopar
+// Un pedido con la fecha_maxima_pago vencida se vence antes de aplicar la transición.
+func (p *PagoparPlugin) transition(hash string, to store.Status, note string, fn func(tx *store.Transaction) error) (*store.Transaction, error) {
+	if _, err := p.findOrder(hash); err != nil {
+		return nil, err
+	}
+	return p.store.Transition(GatewayName, hash, to, note, fn)
+}
+
+// setPaidMeta registra el comprobante del pago y limpia el error del último intento
+func setPaidMeta(tx *store.Transaction) error {
+	tx.SetMeta(MetaFormaPago, firstNonEmpty(tx.Meta(MetaFormaPago), DefaultFormaPago))
+	tx.SetMeta(MetaComprobante, generateComprobante())
+	tx.SetMeta(MetaUltimoError, "")
+	return nil
+}
+
 // Funciones auxiliares
 
 func generateOrderHash() string {
-	return "ad57c9c94f745fdd9bc9093bb409297607264af1a904e6300e71c24f15d6" + fmt.Sprintf("%03d", 100+rand.Intn(900))
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%064x", mathrand.Int63())
+	}
+	return hex.EncodeToString(buf)
 }
 
 func generateOrderNumber() string {
-	return fmt.Sprintf("%d", 1750+rand.Intn(1000))
+	return fmt.Sprintf("%d", 1750+mathrand.Intn(100000))
 }
 
-func generateToken(hash string) string {
-	return fmt.Sprintf("token_%s", hash[:10])
+func generateComprobante() string {
+	return fmt.Sprintf("%d", 8000000+mathrand.Intn(1000000))
 }
 
-func generateWebhookToken(hashPedido string) string {
-	tokenSecret := "test_webhook_secret_123"
-	data := tokenSecret + hashPedido
-	hash := md5.Sum([]byte(data))
-	return fmt.Sprintf("%x", hash)
+// findPaymentMethod busca una forma de pago por su identificador
+func findPaymentMethod(formaPago string) (PagoparPaymentMethod, bool) {
+	for _, method := range getPaymentMethods() {
+		if method.FormaPago == formaPago {
+			return method, true
+		}
+	}
+	return PagoparPaymentMethod{}, false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
 }
 
 func getPaymentMethods() []PagoparPaymentMethod {
@@ -444,46 +722,135 @@ func getPaymentMethods() []PagoparPaymentMethod {
 	}
 }
 
-func getOrderStatus(hash string) PagoparOrderStatusData {
-	return PagoparOrderStatusData{
-		Pagado:                   true,
-		NumeroComprobanteInterno: "8230473",
-		UltimoMensajeError:       nil,
-		FormaPago:                "Tarjetas de crédito/débito",
-		FechaPago:                "2025-09-16 12:30:45.123456",
-		Monto:                    "100000.00",
-		FechaMaximaPago:          "2025-09-23 14:14:48",
-		HashPedido:               hash,
-		NumeroPedido:             "1746",
-		Cancelado:                false,
-		FormaPagoIdentificador:   "1",
-		Token:                    generateToken(hash),
-		MensajeResultadoPago: map[string]interface{}{
+// buildOrderStatus arma los datos de estado de pedido a partir de la transacción almacenada
+func (p *PagoparPlugin) buildOrderStatus(tx *store.Transaction) PagoparOrderStatusData {
+	isPaid := tx.Status == store.StatusPaid
+
+	var fechaPago interface{} = nil
+	var mensajeResultado interface{} = nil
+	if paidAt, ok := tx.StatusAt(store.StatusPaid); ok && isPaid {
+		fechaPago = paidAt.Format("2006-01-02 15:04:05.000000")
+		mensajeResultado = map[string]interface{}{
 			"titulo":      "Pago procesado exitosamente",
-			"descripcion": "Comprobante: 8230473. Tu pago ha sido procesado correctamente.",
-		},
+			"descripcion": fmt.Sprintf("Comprobante: %s. Tu pago ha sido procesado correctamente.", tx.Meta(MetaComprobante)),
+		}
+	} else if hasPendingVoucher(tx) {
+		voucher := buildVoucher(tx)
+		mensajeResultado = map[string]interface{}{
+			"titulo":      "Pedido pendiente de pago",
+			"descripcion": fmt.Sprintf("Acercate a una boca de %s con el código de pago %s antes del %s.", voucher.RedCobranza, voucher.CodigoPago, voucher.FechaMaximaPago),
+		}
 	}
-}
 
-func generateWebhookData(hash, result string) PagoparOrderStatusData {
-	isPaid := result == PaymentStatusSuccess
-	var fechaPago interface{} = nil
-	if isPaid {
-		fechaPago = "2025-09-16 12:30:45.123456"
+	var ultimoError interface{} = nil
+	if message := tx.Meta(MetaUltimoError); message != "" && !isPaid {
+		ultimoError = message
+	}
+
+	// La forma de pago se conoce recién cuando el comprador la elige en el checkout
+	formaPago := ""
+	if method, ok := findPaymentMethod(tx.Meta(MetaFormaPago)); ok {
+		formaPago = method.Titulo
 	}
 
 	return PagoparOrderStatusData{
 		Pagado:                   isPaid,
-		NumeroComprobanteInterno: "8230473",
-		UltimoMensajeError:       nil,
-		FormaPago:                "Tarjetas de crédito/débito",
+		NumeroComprobanteInterno: tx.Meta(MetaComprobante),
+		UltimoMensajeError:       ultimoError,
+		FormaPago:                formaPago,
 		FechaPago:                fechaPago,
-		Monto:                    "100000.00",
-		FechaMaximaPago:          "2025-09-23 14:14:48",
-		HashPedido:               hash,
-		NumeroPedido:             "1750",
-		Cancelado:                result == PaymentStatusCancel,
-		FormaPagoIdentificador:   "9",
-		Token:                    generateToken(hash),
+		Monto:                    store.FormatAmount(tx.Amount),
+		FechaMaximaPago:          fechaMaximaPago(tx).Format(DateFormat),
+		HashPedido:               tx.ID,
+		NumeroPedido:             tx.Reference,
+		Cancelado:                tx.Status == store.StatusCancelled || tx.Status == store.StatusExpired,
+		FormaPagoIdentificador:   tx.Meta(MetaFormaPago),
+		Token:                    p.orderWebhookToken(tx),
+		MensajeResultadoPago:     mensajeResultado,
+	}
+}
+
+// fechaMaximaPago devuelve la fecha límite para pagar el pedido
+func fechaMaximaPago(tx *store.Transaction) time.Time {
+	if fecha, err := time.ParseInLocation(DateFormat, tx.Meta(MetaFechaMaxima), time.Local); err == nil {
+		return fecha
+	}
+	return tx.CreatedAt.Add(DefaultPaymentWindow)
+}
+
+// parseFechaMaximaPago valida la fecha_maxima_pago del pedido ("2006-01-02 15:04:05", hora local).
+// Sin fecha el pedido vence en DefaultPaymentWindow.
+func parseFechaMaximaPago(value string) (time.Time, error) {
+	if value == "" {
+		return clock.Now().Add(DefaultPaymentWindow), nil
+	}
+
+	fecha, err := time.ParseInLocation(DateFormat, value, time.Local)
+	if err != nil {
+		return time.Time{}, newPagoparError(http.StatusBadRequest, "Formato de fecha_maxima_pago inválido, se espera AAAA-MM-DD HH:MM:SS")
+	}
+	if !fecha.After(clock.Now()) {
+		return time.Time{}, newPagoparError(http.StatusBadRequest, "La fecha máxima de pago debe ser posterior a la fecha actual")
+	}
+	return fecha, nil
+}
+
+// checkIdPedido rechaza un id_pedido_comercio ya usado por otro pedido del comercio
+func (p *PagoparPlugin) checkIdPedido(publicKey, idPedido string) *PagoparError {
+	_, err := p.store.Find(GatewayName, func(tx *store.Transaction) bool {
+		return tx.Meta(MetaIdPedido) == idPedido && tx.Meta(MetaPublicKey) == publicKey
+	})
+	if err == nil {
+		return newPagoparError(http.StatusBadRequest, "Ya existe un pedido con id_pedido_comercio "+idPedido)
+	}
+	return nil
+}
+
+// parseItems convierte los compras_items del pedido y valida que sumen el monto_total
+func parseItems(comprasItems []PagoparComprasItem, montoTotal float64) ([]store.Item, error) {
+	items := make([]store.Item, 0, len(comprasItems))
+	var suma float64
+	for _, item := range comprasItems {
+		cantidad := 1
+		if item.Cantidad != "" {
+			parsed, err := strconv.Atoi(item.Cantidad.String())
+			if err != nil || parsed <= 0 {
+				return nil, newPagoparError(http.StatusBadRequest, "Cantidad inválida en el item: "+item.Nombre)
+			}
+			cantidad = parsed
+		}
+
+		var total float64
+		switch {
+		case item.PrecioTotal != "":
+			precioTotal, err := store.ParseAmount(item.PrecioTotal.String())
+			if err != nil {
+				return nil, newPagoparError(http.StatusBadRequest, "Precio inválido en el item: "+item.Nombre)
+			}
+			total = precioTotal
+		case item.Precio != "":
+			precio, err := store.ParseAmount(item.Precio.String())
+			if err != nil {
+				return nil, newPagoparError(http.StatusBadRequest, "Precio inválido en el item: "+item.Nombre)
+			}
+			total = precio * float64(cantidad)
+		default:
+			return nil, newPagoparError(http.StatusBadRequest, "Falta precio_total en el item: "+item.Nombre)
+		}
+
+		suma += total
+		items = append(items, store.Item{
+			Name:        item.Nombre,
+			Description: item.Descripcion,
+			Quantity:    cantidad,
+			Amount:      total,
+		})
+	}
+
+	if math.Abs(suma-montoTotal) >= 0.005 {
+		return nil, newPagoparError(http.StatusBadRequest, fmt.Sprintf(
+			"La suma de los precios de los productos (%s) no coincide con el monto total del pedido (%s)",
+			phpAmount(suma), phpAmount(montoTotal)))
 	}
+	return items, nil
 }
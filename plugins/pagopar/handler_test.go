@@ -0,0 +1,214 @@
+package pagopar
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"payment-emulator/internal/plugins"
+	"payment-emulator/internal/store"
+	"payment-emulator/internal/webhook"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Comercios de prueba: cada test opera con dos comercios para verificar el aislamiento entre ellos
+var (
+	merchantA = plugins.Merchant{Name: "Comercio A", PublicKey: "pk_comercio_a", PrivateKey: "sk_comercio_a"}
+	merchantB = plugins.Merchant{Name: "Comercio B", PublicKey: "pk_comercio_b", PrivateKey: "sk_comercio_b"}
+)
+
+// testServer es el plugin de Pagopar montado sobre un store propio
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	plugin *PagoparPlugin
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	orders := store.NewStore()
+	orders.RegisterLifecycle(GatewayName, pagoparLifecycle)
+
+	plugin := &PagoparPlugin{
+		name:       "Pagopar",
+		pluginType: "popup",
+		config:     &plugins.Plugin{Name: "Pagopar", Merchants: []plugins.Merchant{merchantA, merchantB}},
+		store:      orders,
+		webhooks:   webhook.NewDispatcher(webhook.DefaultConfig, 1),
+	}
+
+	router := gin.New()
+	plugin.SetupRoutes(router)
+	return &testServer{t: t, router: router, plugin: plugin}
+}
+
+// do envía la petición y decodifica la respuesta JSON en out (si no es nil)
+func (s *testServer) do(method, path string, body interface{}, out interface{}) int {
+	s.t.Helper()
+
+	var reader *bytes.Reader
+	if body == nil {
+		reader = bytes.NewReader(nil)
+	} else {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("marshal %s: %v", path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	request := httptest.NewRequest(method, path, reader)
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, request)
+
+	if out != nil {
+		if err := json.Unmarshal(recorder.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s: invalid JSON response %q: %v", method, path, recorder.Body.String(), err)
+		}
+	}
+	return recorder.Code
+}
+
+// orderRequest arma un iniciar-transaccion firmado por el comercio con un único item
+func orderRequest(merchant plugins.Merchant, idPedido string, monto float64) gin.H {
+	return gin.H{
+		"token":              orderToken(merchant.PrivateKey, idPedido, monto),
+		"public_key":         merchant.PublicKey,
+		"id_pedido_comercio": idPedido,
+		"monto_total":        monto,
+		"comprador":          gin.H{"email": "comprador@example.com", "nombre": "Comprador"},
+		"compras_items": []gin.H{
+			{"nombre": "Producto", "cantidad": 1, "precio_total": monto},
+		},
+	}
+}
+
+// createOrder crea un pedido del comercio y devuelve su hash
+func (s *testServer) createOrder(merchant plugins.Merchant, idPedido string, monto float64) string {
+	s.t.Helper()
+	var response PagoparOrderResponse
+	if code := s.do(http.MethodPost, "/api/comercios/2.0/iniciar-transaccion", orderRequest(merchant, idPedido, monto), &response); code != http.StatusOK {
+		s.t.Fatalf("iniciar-transaccion %s = %d, want 200", idPedido, code)
+	}
+	return response.Resultado[0].Data
+}
+
+// orderStatus consulta el pedido con pedidos/1.1/traer firmado por el comercio
+func (s *testServer) orderStatus(merchant plugins.Merchant, hash string, out interface{}) int {
+	s.t.Helper()
+	return s.do(http.MethodPost, "/api/pedidos/1.1/traer", gin.H{
+		"hash_pedido":   hash,
+		"token":         queryToken(merchant.PrivateKey),
+		"token_publico": merchant.PublicKey,
+	}, out)
+}
+
+// order devuelve el estado almacenado del pedido
+func (s *testServer) order(hash string) *store.Transaction {
+	s.t.Helper()
+	tx, err := s.plugin.store.Get(GatewayName, hash)
+	if err != nil {
+		s.t.Fatalf("Get(%s): %v", hash, err)
+	}
+	return tx
+}
+
+func TestOrderStatusOfAnotherMerchantIsNotFound(t *testing.T) {
+	s := newTestServer(t)
+	hash := s.createOrder(merchantA, "1134", 100000)
+
+	var own PagoparOrderStatusResponse
+	if code := s.orderStatus(merchantA, hash, &own); code != http.StatusOK || len(own.Resultado) != 1 || own.Resultado[0].HashPedido != hash {
+		t.Fatalf("pedidos/1.1/traer by owner = %d %+v, want 200 with the order", code, own)
+	}
+
+	var foreign PagoparErrorResponse
+	code := s.orderStatus(merchantB, hash, &foreign)
+	if code != http.StatusNotFound || foreign.Resultado != "No existe pedido" {
+		t.Errorf("pedidos/1.1/traer by another merchant = %d %q, want 404 \"No existe pedido\"", code, foreign.Resultado)
+	}
+}
+
+func TestIniciarTransaccionRejectsInvalidAmounts(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, monto := range []string{"NaN", "Inf", "0", "-100000"} {
+		request := orderRequest(merchantA, "1134", 100000)
+		request["monto_total"] = monto
+		request["compras_items"] = []gin.H{{"nombre": "Producto", "cantidad": 1, "precio_total": monto}}
+
+		var response PagoparErrorResponse
+		if code := s.do(http.MethodPost, "/api/comercios/2.0/iniciar-transaccion", request, &response); code != http.StatusBadRequest {
+			t.Errorf("iniciar-transaccion monto_total %q = %d %q, want 400", monto, code, response.Resultado)
+		}
+	}
+
+	// Un precio NaN no puede saltear la validación de la suma de los items
+	request := orderRequest(merchantA, "1134", 100000)
+	request["compras_items"] = []gin.H{{"nombre": "Producto", "cantidad": 1, "precio_total": "NaN"}}
+	var response PagoparErrorResponse
+	if code := s.do(http.MethodPost, "/api/comercios/2.0/iniciar-transaccion", request, &response); code != http.StatusBadRequest {
+		t.Errorf("iniciar-transaccion with precio_total NaN = %d %q, want 400", code, response.Resultado)
+	}
+
+	if orders := s.plugin.store.List(GatewayName); len(orders) != 0 {
+		t.Errorf("invalid amounts created %d orders", len(orders))
+	}
+}
+
+func TestIniciarTransaccionRequiresUniqueIdPedido(t *testing.T) {
+	s := newTestServer(t)
+	s.createOrder(merchantA, "2245", 100000)
+
+	// El mismo id_pedido_comercio puede usarlo otro comercio
+	s.createOrder(merchantB, "2245", 100000)
+
+	var duplicated PagoparErrorResponse
+	if code := s.do(http.MethodPost, "/api/comercios/2.0/iniciar-transaccion", orderRequest(merchantA, "2245", 100000), &duplicated); code != http.StatusBadRequest {
+		t.Errorf("repeated iniciar-transaccion = %d %q, want 400", code, duplicated.Resultado)
+	}
+
+	request := orderRequest(merchantA, "", 100000)
+	delete(request, "id_pedido_comercio")
+	var missing PagoparErrorResponse
+	if code := s.do(http.MethodPost, "/api/comercios/2.0/iniciar-transaccion", request, &missing); code != http.StatusBadRequest {
+		t.Errorf("iniciar-transaccion without id_pedido_comercio = %d %q, want 400", code, missing.Resultado)
+	}
+
+	if orders := s.plugin.store.List(GatewayName); len(orders) != 2 {
+		t.Errorf("store has %d orders, want 2", len(orders))
+	}
+}
+
+func TestIniciarTransaccionValidatesItemsSum(t *testing.T) {
+	s := newTestServer(t)
+
+	request := orderRequest(merchantA, "3356", 100000)
+	request["compras_items"] = []gin.H{
+		{"nombre": "Producto", "cantidad": 1, "precio_total": 60000},
+		{"nombre": "Envío", "cantidad": 1, "precio_total": "30000"},
+	}
+	var response PagoparErrorResponse
+	code := s.do(http.MethodPost, "/api/comercios/2.0/iniciar-transaccion", request, &response)
+	if code != http.StatusBadRequest || !strings.Contains(response.Resultado, "no coincide con el monto total") {
+		t.Errorf("iniciar-transaccion with items summing 90000 = %d %q, want 400 sum mismatch", code, response.Resultado)
+	}
+
+	request["compras_items"] = []gin.H{
+		{"nombre": "Producto", "cantidad": 1, "precio_total": 60000},
+		{"nombre": "Envío", "cantidad": 1, "precio_total": "40000"},
+	}
+	var order PagoparOrderResponse
+	if code := s.do(http.MethodPost, "/api/comercios/2.0/iniciar-transaccion", request, &order); code != http.StatusOK {
+		t.Fatalf("iniciar-transaccion with items summing 100000 = %d, want 200", code)
+	}
+	if tx := s.order(order.Resultado[0].Data); len(tx.Items) != 2 || tx.Amount != 100000 {
+		t.Errorf("order has %d items for %.2f, want 2 items for 100000", len(tx.Items), tx.Amount)
+	}
+}
@@ -0,0 +1,44 @@
+package pagopar
+
+import (
+	"net/http"
+	"payment-emulator/internal/store"
+)
+
+// pagoparLifecycle define las transiciones permitidas para pedidos de Pagopar.
+// Un intento fallido no cierra el pedido: el comprador puede reintentar con otra forma de pago.
+// Un pedido pendiente (por ejemplo, con un código de cobranza emitido) también admite cambiar de forma de pago.
+var pagoparLifecycle = store.Lifecycle{
+	Transitions: map[store.Status][]store.Status{
+		store.StatusCreated: {store.StatusPending, store.StatusPaid, store.StatusFailed, store.StatusCancelled, store.StatusExpired},
+		store.StatusPending: {store.StatusPending, store.StatusPaid, store.StatusFailed, store.StatusCancelled, store.StatusExpired},
+		store.StatusFailed:  {store.StatusPending, store.StatusPaid, store.StatusFailed, store.StatusCancelled, store.StatusExpired},
+		store.StatusPaid:    {store.StatusReversed},
+	},
+	Reject: rejectTransition,
+}
+
+// rejectTransition traduce una transición inválida al error equivalente de Pagopar
+func rejectTransition(err *store.TransitionError) error {
+	return closedOrderError(err.From)
+}
+
+// closedOrderError devuelve el error de Pagopar para un pedido que ya no admite pagos
+func closedOrderError(from store.Status) *PagoparError {
+	switch from {
+	case store.StatusPaid:
+		return newPagoparError(http.StatusConflict, "El pedido ya fue pagado")
+	case store.StatusCancelled:
+		return newPagoparError(http.StatusConflict, "El pedido fue cancelado")
+	case store.StatusExpired:
+		return newPagoparError(http.StatusConflict, "El pedido ya expiró")
+	case store.StatusReversed:
+		return newPagoparError(http.StatusConflict, "El pago del pedido fue revertido")
+	default:
+		return newPagoparError(http.StatusConflict, "El pedido no admite esta operación (estado: "+string(from)+")")
+	}
+}
+
+func init() {
+	store.GetGlobalStore().RegisterLifecycle(GatewayName, pagoparLifecycle)
+}
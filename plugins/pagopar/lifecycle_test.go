@@ -0,0 +1,56 @@
+package pagopar
+
+import (
+	"payment-emulator/internal/store"
+	"testing"
+)
+
+func TestPagoparLifecycleAllows(t *testing.T) {
+	tests := []struct {
+		from, to store.Status
+		want     bool
+	}{
+		{store.StatusCreated, store.StatusPending, true},
+		{store.StatusCreated, store.StatusPaid, true},
+		{store.StatusCreated, store.StatusRefunded, false},
+		{store.StatusPending, store.StatusPending, true},
+		{store.StatusPending, store.StatusExpired, true},
+		{store.StatusFailed, store.StatusPaid, true},
+		{store.StatusFailed, store.StatusFailed, true},
+		{store.StatusPaid, store.StatusReversed, true},
+		{store.StatusPaid, store.StatusRefunded, false},
+		{store.StatusPaid, store.StatusExpired, false},
+		{store.StatusCancelled, store.StatusPaid, false},
+		{store.StatusExpired, store.StatusPaid, false},
+		{store.StatusReversed, store.StatusPaid, false},
+	}
+
+	for _, tt := range tests {
+		if got := pagoparLifecycle.Allows(tt.from, tt.to); got != tt.want {
+			t.Errorf("Allows(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestPagoparLifecycleReject(t *testing.T) {
+	tests := []struct {
+		from store.Status
+		want string
+	}{
+		{store.StatusPaid, "El pedido ya fue pagado"},
+		{store.StatusCancelled, "El pedido fue cancelado"},
+		{store.StatusExpired, "El pedido ya expiró"},
+		{store.StatusReversed, "El pago del pedido fue revertido"},
+	}
+
+	for _, tt := range tests {
+		err := rejectTransition(&store.TransitionError{Gateway: GatewayName, ID: "hash", From: tt.from, To: store.StatusPaid})
+		pagoparError, ok := err.(*PagoparError)
+		if !ok {
+			t.Fatalf("rejectTransition(%s) = %T, want *PagoparError", tt.from, err)
+		}
+		if pagoparError.Message != tt.want {
+			t.Errorf("rejectTransition(%s) = %q, want %q", tt.from, pagoparError.Message, tt.want)
+		}
+	}
+}
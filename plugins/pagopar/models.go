@@ -1,41 +1,97 @@
 package pagopar
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // PagoparOrderRequest representa la petición para iniciar transacción en Pagopar
 type PagoparOrderRequest struct {
-	Token          string                 `json:"token" binding:"required"`
-	PublicKey      string                 `json:"public_key" binding:"required"`
-	MontoTotal     string                 `json:"monto_total" binding:"required"`
-	Comprador      PagoparComprador       `json:"comprador" binding:"required"`
-	ComprasItems   []PagoparComprasItem   `json:"compras_items" binding:"required"`
-	UrlResultado   string                 `json:"url_resultado,omitempty"`
-	UrlCancelacion string                 `json:"url_cancelacion,omitempty"`
-	UrlRespuesta   string                 `json:"url_respuesta,omitempty"`
-	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	Token              string                 `json:"token" binding:"required"`
+	PublicKey          string                 `json:"public_key" binding:"required"`
+	IdPedidoComercio   PagoparValue           `json:"id_pedido_comercio" binding:"required"`
+	TipoPedido         string                 `json:"tipo_pedido,omitempty"`
+	MontoTotal         PagoparValue           `json:"monto_total" binding:"required"`
+	FechaMaximaPago    string                 `json:"fecha_maxima_pago,omitempty"`
+	DescripcionResumen string                 `json:"descripcion_resumen,omitempty"`
+	FormaPago          PagoparValue           `json:"forma_pago,omitempty"`
+	Comprador          PagoparComprador       `json:"comprador" binding:"required"`
+	ComprasItems       []PagoparComprasItem   `json:"compras_items" binding:"required,min=1,dive"`
+	UrlResultado       string                 `json:"url_resultado,omitempty"`
+	UrlCancelacion     string                 `json:"url_cancelacion,omitempty"`
+	UrlRespuesta       string                 `json:"url_respuesta,omitempty"`
+	Metadata           map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // PagoparComprador representa los datos del comprador
 type PagoparComprador struct {
-	Email     string `json:"email" binding:"required"`
-	Telefono  string `json:"telefono,omitempty"`
-	Documento string `json:"documento,omitempty"`
-	Nombre    string `json:"nombre,omitempty"`
+	Email               string       `json:"email" binding:"required"`
+	Nombre              string       `json:"nombre,omitempty"`
+	Telefono            string       `json:"telefono,omitempty"`
+	Documento           PagoparValue `json:"documento,omitempty"`
+	TipoDocumento       string       `json:"tipo_documento,omitempty"` // CI, RUC, PAS
+	Ruc                 string       `json:"ruc,omitempty"`
+	RazonSocial         string       `json:"razon_social,omitempty"`
+	Ciudad              PagoparValue `json:"ciudad,omitempty"` // Identificador de ciudad de Pagopar
+	Direccion           string       `json:"direccion,omitempty"`
+	DireccionReferencia string       `json:"direccion_referencia,omitempty"`
+	Coordenadas         string       `json:"coordenadas,omitempty"` // "latitud,longitud"
 }
 
-// PagoparComprasItem representa un item de compra
+// PagoparComprasItem representa un item de compra. precio_total es el total de la línea;
+// precio (precio unitario) se mantiene por compatibilidad con pedidos anteriores del emulador.
 type PagoparComprasItem struct {
-	Nombre      string `json:"nombre" binding:"required"`
-	Precio      string `json:"precio" binding:"required"`
-	Cantidad    int    `json:"cantidad" binding:"required"`
-	Descripcion string `json:"descripcion,omitempty"`
+	Nombre                       string       `json:"nombre" binding:"required"`
+	Cantidad                     PagoparValue `json:"cantidad"`
+	PrecioTotal                  PagoparValue `json:"precio_total"`
+	Precio                       PagoparValue `json:"precio,omitempty"`
+	Descripcion                  string       `json:"descripcion,omitempty"`
+	IdProducto                   PagoparValue `json:"id_producto,omitempty"`
+	Categoria                    PagoparValue `json:"categoria,omitempty"`
+	Ciudad                       PagoparValue `json:"ciudad,omitempty"`
+	UrlImagen                    string       `json:"url_imagen,omitempty"`
+	PublicKey                    string       `json:"public_key,omitempty"`
+	VendedorTelefono             string       `json:"vendedor_telefono,omitempty"`
+	VendedorDireccion            string       `json:"vendedor_direccion,omitempty"`
+	VendedorDireccionReferencia  string       `json:"vendedor_direccion_referencia,omitempty"`
+	VendedorDireccionCoordenadas string       `json:"vendedor_direccion_coordenadas,omitempty"`
+}
+
+// PagoparValue acepta un valor JSON enviado como número o como string (los SDK de Pagopar
+// envían montos, cantidades e identificadores de las dos formas) y lo guarda como texto
+type PagoparValue string
+
+// UnmarshalJSON decodifica números, strings y null
+func (v *PagoparValue) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*v = PagoparValue(strings.TrimSpace(text))
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		if string(data) == "null" {
+			*v = ""
+			return nil
+		}
+		return fmt.Errorf("se esperaba un número o un string: %s", data)
+	}
+	*v = PagoparValue(number.String())
+	return nil
+}
+
+// String devuelve el valor como texto
+func (v PagoparValue) String() string {
+	return string(v)
 }
 
 // PagoparOrderResponse representa la respuesta de creación de orden
 type PagoparOrderResponse struct {
 	Respuesta bool                 `json:"respuesta"`
 	Resultado []PagoparOrderResult `json:"resultado,omitempty"`
-	Error     string               `json:"error,omitempty"`
 }
 
 // PagoparOrderResult representa el resultado de la orden
@@ -66,6 +122,17 @@ type PagoparPaymentMethod struct {
 	PagosInternacionales bool   `json:"pagos_internacionales,omitempty"`
 }
 
+// PagoparCashVoucher representa el código de pago emitido para pagar un pedido en una red de cobranza
+type PagoparCashVoucher struct {
+	CodigoPago      string `json:"codigo_pago"`
+	RedCobranza     string `json:"red_cobranza"`
+	FormaPago       string `json:"forma_pago"`
+	HashPedido      string `json:"hash_pedido"`
+	NumeroPedido    string `json:"numero_pedido"`
+	Monto           string `json:"monto"`
+	FechaMaximaPago string `json:"fecha_maxima_pago"`
+}
+
 // PagoparOrderStatusRequest representa la petición para consultar estado de pedido
 type PagoparOrderStatusRequest struct {
 	HashPedido   string `json:"hash_pedido" binding:"required"`
@@ -96,6 +163,13 @@ type PagoparOrderStatusData struct {
 	MensajeResultadoPago     interface{} `json:"mensaje_resultado_pago,omitempty"`
 }
 
+// PagoparWebhookRequest representa la confirmación o reversión de un pago enviada por el comercio
+type PagoparWebhookRequest struct {
+	HashPedido   string `json:"hash_pedido" binding:"required"`
+	Token        string `json:"token" binding:"required"`
+	TokenPublico string `json:"token_publico" binding:"required"`
+}
+
 // PagoparWebhookData representa los datos del webhook de Pagopar
 type PagoparWebhookData struct {
 	Resultado []PagoparOrderStatusData `json:"resultado"`
@@ -129,6 +203,9 @@ type PagoparSimulationResult struct {
 
 // Constantes para Pagopar
 const (
+	// Nombre del gateway en el store de transacciones
+	GatewayName = "pagopar"
+
 	// Estados de pago
 	PaymentStatusSuccess = "success"
 	PaymentStatusError   = "error"
@@ -142,7 +219,44 @@ const (
 	PaymentMethodPIX       = "25"
 	PaymentMethodQR        = "24"
 
+	// Redes de cobranza: el comprador recibe un código y paga después en una boca de cobranza
+	PaymentMethodAquiPago    = "2"
+	PaymentMethodPagoExpress = "3"
+	PaymentMethodInfonet     = "15"
+	PaymentMethodWepa        = "22"
+
 	// Configuración por defecto
-	DefaultCurrency = "PYG"
-	DefaultAmount   = "100000.00"
+	DefaultCurrency  = "PYG"
+	DefaultAmount    = "100000.00"
+	DefaultFormaPago = PaymentMethodCredit
+
+	// Plazo para pagar un pedido cuando el comercio no indica fecha_maxima_pago
+	DefaultPaymentWindow = 48 * time.Hour
+
+	// Tipos de pedido de iniciar-transaccion
+	TipoPedidoVentaComercio    = "VENTA-COMERCIO"
+	TipoPedidoComercioHeredado = "COMERCIO-HEREDADO"
+
+	// Marcador de url_resultado que Pagopar reemplaza por el hash del pedido
+	HashPlaceholder = "($hash)"
+
+	// Formato de fechas de la API de Pagopar
+	DateFormat = "2006-01-02 15:04:05"
+
+	// Claves de metadata en el store
+	MetaUrlRespuesta  = "url_respuesta"
+	MetaComprobante   = "numero_comprobante_interno"
+	MetaPublicKey     = "public_key"
+	MetaFormaPago     = "forma_pago"
+	MetaTipoPedido    = "tipo_pedido"
+	MetaIdPedido      = "id_pedido_comercio"
+	MetaFechaMaxima   = "fecha_maxima_pago"
+	MetaRuc           = "ruc"
+	MetaRazonSocial   = "razon_social"
+	MetaCiudad        = "ciudad"
+	MetaDireccion     = "direccion"
+	MetaCoordenadas   = "coordenadas"
+	MetaUltimoError   = "ultimo_mensaje_error"
+	MetaCodigoPago    = "codigo_pago"
+	MetaRedCodigoPago = "codigo_pago_forma_pago"
 )
@@ -5,6 +5,7 @@ func GetPagoparTemplates() map[string]string {
 	return map[string]string{
 		"pagopar_checkout.html":  pagoparCheckoutHTML,
 		"pagopar_result.html":    pagoparResultHTML,
+		"pagopar_voucher.html":   pagoparVoucherHTML,
 		"pagopar_docs.html":      pagoparDocsHTML,
 		"webhook_simulator.html": webhookSimulatorHTML,
 	}
@@ -32,17 +33,21 @@ const pagoparCheckoutHTML = `<!DOCTYPE html>
         <h1>Pagopar - Checkout</h1>
         <div class="info">
             <p><strong>Hash del pedido:</strong> {{.hash}}</p>
+            <p><strong>Número de pedido:</strong> {{.pedido}}</p>
+            <p><strong>Monto:</strong> Gs. {{.monto}}</p>
+            {{if .descripcion}}<p><strong>Descripción:</strong> {{.descripcion}}</p>{{end}}
             <p><strong>Forma de pago seleccionada:</strong> {{.formaPago}}</p>
         </div>
         
         <h2>Seleccionar método de pago:</h2>
         {{range .methods}}
-        <div class="method" onclick="selectMethod('{{.FormaPago}}')">
+        <div class="method{{if eq .FormaPago $.formaPago}} selected{{end}}" onclick="selectMethod(event, '{{.FormaPago}}')">
             <h3>{{.Titulo}}</h3>
             <p>{{.Descripcion}}</p>
             <small>Comisión: {{.PorcentajeComision}}% - Mínimo: Gs. {{.MontoMinimo}}</small>
         </div>
         {{end}}
+        <p><small>Con Aqui Pago, Pago Express, Infonet Cobranzas o Wepa el pago exitoso emite un código de pago y el pedido queda pendiente hasta que se pague en una boca de cobranza.</small></p>
         
         <div style="margin-top: 30px;">
             <button onclick="processPayment('success')">Simular Pago Exitoso</button>
@@ -55,31 +60,57 @@ const pagoparCheckoutHTML = `<!DOCTYPE html>
             <p><small>Esto simulará el flujo completo con redirect a tu aplicación</small></p>
             <button onclick="processPaymentWithRedirect('success')" style="background: #28a745;"> Pagar y Redirigir (Éxito)</button>
             <button onclick="processPaymentWithRedirect('error')" style="background: #dc3545; margin-left: 10px;"> Pagar y Redirigir (Error)</button>
+            <p><a href="/pagos/{{.hash}}/cancelar">Volver al comercio sin pagar</a></p>
         </div>
     </div>
     
     <script>
-        function selectMethod(id) {
+        let formaPago = '{{.formaPago}}';
+        
+        function selectMethod(event, id) {
+            formaPago = id;
             document.querySelectorAll('.method').forEach(m => m.classList.remove('selected'));
             event.target.closest('.method').classList.add('selected');
         }
         
         function processPayment(result) {
             alert('Simulando resultado: ' + result);
-            fetch('/emulator/webhook/{{.hash}}?result=' + result, {
+            const query = '?result=' + result + (formaPago ? '&forma_pago=' + encodeURIComponent(formaPago) : '');
+            fetch('/emulator/webhook/{{.hash}}' + query, {
                 method: 'POST'
             }).then(response => response.json())
             .then(data => {
+                if (data.respuesta === false) {
+                    alert(data.resultado);
+                    return;
+                }
                 console.log('Webhook simulado:', data);
+                if (data.voucher) {
+                    window.location.href = '/pagos/{{.hash}}/comprobante';
+                    return;
+                }
                 window.location.href = '/emulator/result?hash={{.hash}}&result=' + result;
             });
         }
         
         function processPaymentWithRedirect(result) {
-            alert('Procesando pago y redirigiendo a la aplicación...');
-            setTimeout(() => {
+            const query = '?result=' + result + (formaPago ? '&forma_pago=' + encodeURIComponent(formaPago) : '');
+            fetch('/emulator/webhook/{{.hash}}' + query, {
+                method: 'POST'
+            }).then(response => response.json())
+            .then(data => {
+                if (data.respuesta === false) {
+                    alert(data.resultado);
+                    return;
+                }
+                // Con una red de cobranza el comprador ve su código de pago antes de volver al comercio
+                if (data.voucher) {
+                    window.location.href = '/pagos/{{.hash}}/comprobante';
+                    return;
+                }
+                // Pagopar devuelve al comprador a url_resultado con el hash del pedido
                 window.location.href = '/resultado/{{.hash}}';
-            }, 2000);
+            });
         }
     </script>
 </body>
@@ -108,10 +139,14 @@ const pagoparResultHTML = `<!DOCTYPE html>
         {{else if eq .result "error"}}
         <div class="status">Error</div>
         <div class="message" style="color: #dc3545;">Error en el Pago</div>
+        {{else if eq .result "cancel"}}
+        <div class="status">Cancelado</div>
+        <div class="message" style="color: #6c757d;">Pago no realizado</div>
         {{else}}
         <div class="status">Pendiente</div>
         <div class="message" style="color: #ffc107;">Pago Pendiente</div>
         {{end}}
+        {{if .message}}<p>{{.message}}</p>{{end}}
         
         <div class="hash">
             <strong>Hash:</strong><br>{{.hash}}
@@ -123,6 +158,55 @@ const pagoparResultHTML = `<!DOCTYPE html>
 </body>
 </html>`
 
+// Template con el código de pago para redes de cobranza
+const pagoparVoucherHTML = `<!DOCTYPE html>
+<html>
+<head>
+    <title>Pagopar - Código de Pago</title>
+    <meta charset="utf-8">
+    <style>
+        body { font-family: Arial, sans-serif; margin: 40px; background: #f5f5f5; text-align: center; }
+        .container { max-width: 500px; margin: 0 auto; background: white; padding: 40px; border-radius: 8px; box-shadow: 0 2px 10px rgba(0,0,0,0.1); }
+        .code { font-size: 36px; font-family: monospace; letter-spacing: 4px; background: #f8f9fa; padding: 20px; border-radius: 4px; margin: 20px 0; }
+        .info { text-align: left; background: #fff3cd; padding: 15px; border-radius: 4px; border: 1px solid #ffeeba; }
+        button { background: #28a745; color: white; border: none; padding: 12px 24px; border-radius: 4px; cursor: pointer; font-size: 16px; margin: 10px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <h1>{{.voucher.RedCobranza}}</h1>
+        <p>Presentá este código en cualquier boca de cobranza de {{.voucher.RedCobranza}}</p>
+        <div class="code">{{.voucher.CodigoPago}}</div>
+        <div class="info">
+            <p><strong>Número de pedido:</strong> {{.voucher.NumeroPedido}}</p>
+            <p><strong>Monto:</strong> Gs. {{.voucher.Monto}}</p>
+            <p><strong>Pagar antes del:</strong> {{.voucher.FechaMaximaPago}}</p>
+        </div>
+
+        <div style="margin-top: 30px; border-top: 1px solid #ddd; padding-top: 20px;">
+            <p><small>El pedido queda pendiente hasta que se pague. Podés simular el pago desde aquí, el dashboard, la CLI o la API del emulador.</small></p>
+            <button onclick="payVoucher()">Simular Pago en Boca de Cobranza</button>
+            <p><a href="/resultado/{{.voucher.HashPedido}}">Volver al comercio</a></p>
+        </div>
+    </div>
+
+    <script>
+        function payVoucher() {
+            fetch('/emulator/cash/{{.voucher.CodigoPago}}/pay', {
+                method: 'POST'
+            }).then(response => response.json())
+            .then(data => {
+                if (data.respuesta === false) {
+                    alert(data.resultado);
+                    return;
+                }
+                window.location.href = '/resultado/{{.voucher.HashPedido}}';
+            });
+        }
+    </script>
+</body>
+</html>`
+
 // Template para documentación de Pagopar
 const pagoparDocsHTML = `<!DOCTYPE html>
 <html>
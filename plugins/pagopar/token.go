@@ -0,0 +1,90 @@
+package pagopar
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"payment-emulator/internal/plugins"
+	"payment-emulator/internal/store"
+	"strconv"
+)
+
+// sha1Hex devuelve el hash SHA1 en hexadecimal de la concatenación de las partes
+func sha1Hex(parts ...string) string {
+	data := ""
+	for _, part := range parts {
+		data += part
+	}
+	return fmt.Sprintf("%x", sha1.Sum([]byte(data)))
+}
+
+// phpAmount formatea un monto como lo hace strval(floatval($monto)) en los SDK de Pagopar
+func phpAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
+// orderToken es el token esperado en iniciar-transaccion:
+// sha1(private_key + id_pedido + strval(floatval(monto_total)))
+func orderToken(privateKey, idPedido string, montoTotal float64) string {
+	return sha1Hex(privateKey, idPedido, phpAmount(montoTotal))
+}
+
+// queryToken es el token esperado al consultar pedidos: sha1(private_key + "CONSULTA")
+func queryToken(privateKey string) string {
+	return sha1Hex(privateKey, "CONSULTA")
+}
+
+// paymentMethodsToken es el token esperado al listar formas de pago: sha1(private_key + "FORMA-PAGO")
+func paymentMethodsToken(privateKey string) string {
+	return sha1Hex(privateKey, "FORMA-PAGO")
+}
+
+// webhookToken es el token que Pagopar envía al comercio: sha1(private_key + hash_pedido)
+func webhookToken(privateKey, hashPedido string) string {
+	return sha1Hex(privateKey, hashPedido)
+}
+
+// findMerchant busca el comercio del token público. Un token público desconocido se rechaza
+// como en producción, salvo en modo permisivo donde se devuelve nil con una advertencia.
+func (p *PagoparPlugin) findMerchant(publicKey string) (*plugins.Merchant, error) {
+	merchant, ok := p.config.FindMerchant(publicKey)
+	if ok {
+		return merchant, nil
+	}
+
+	if p.config.IsLenient() {
+		fmt.Printf("Pagopar: token público desconocido %q (aceptado en modo permisivo)\n", publicKey)
+		return nil, nil
+	}
+
+	return nil, newPagoparError(http.StatusUnauthorized, "No existe comercio con ese token público.")
+}
+
+// verifyToken valida un token recibido contra el esperado para el comercio del token público
+// y devuelve el comercio. En modo permisivo los tokens inválidos solo generan una advertencia.
+func (p *PagoparPlugin) verifyToken(publicKey, received string, expected func(privateKey string) string) (*plugins.Merchant, error) {
+	merchant, err := p.findMerchant(publicKey)
+	if err != nil || merchant == nil {
+		return nil, err
+	}
+
+	if received == expected(merchant.PrivateKey) {
+		return merchant, nil
+	}
+
+	if p.config.IsLenient() {
+		fmt.Printf("Pagopar: token inválido para %s (aceptado en modo permisivo)\n", publicKey)
+		return merchant, nil
+	}
+
+	return nil, newPagoparError(http.StatusUnauthorized, "Token no corresponde.")
+}
+
+// orderWebhookToken devuelve el token del pedido firmado con la clave privada de su comercio
+func (p *PagoparPlugin) orderWebhookToken(tx *store.Transaction) string {
+	privateKey := ""
+	if merchant, ok := p.config.FindMerchant(tx.Meta(MetaPublicKey)); ok {
+		privateKey = merchant.PrivateKey
+	}
+	return webhookToken(privateKey, tx.ID)
+}
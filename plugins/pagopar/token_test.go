@@ -0,0 +1,52 @@
+package pagopar
+
+import "testing"
+
+func TestPhpAmount(t *testing.T) {
+	// strval(floatval($monto_total)) en PHP
+	tests := []struct {
+		amount float64
+		want   string
+	}{
+		{100000, "100000"},
+		{100000.5, "100000.5"},
+		{1e6, "1000000"},
+		{1500.25, "1500.25"},
+		{0, "0"},
+	}
+
+	for _, tt := range tests {
+		if got := phpAmount(tt.amount); got != tt.want {
+			t.Errorf("phpAmount(%v) = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+// Vectores armados con la clave privada del comercio de prueba y las fórmulas de la documentación
+// de Pagopar. El hash esperado es el de la cadena documentada en preimage
+// (echo -n "<preimage>" | sha1sum), no el que produce el código probado.
+func TestTokens(t *testing.T) {
+	const (
+		privateKey = "sk_test_pagopar"
+		hash       = "ad57c9c94f745fdd9bc9093bb409297607264af1a904e6300e71c24f15618100"
+	)
+
+	tests := []struct {
+		name     string
+		got      string
+		preimage string
+		want     string
+	}{
+		{"iniciar-transaccion", orderToken(privateKey, "1134", 100000), "sk_test_pagopar1134100000", "0fc90bd9c988e97f19d4ae4a5f71c2903bd6fbcf"},
+		{"iniciar-transaccion con decimales", orderToken(privateKey, "1134", 100000.5), "sk_test_pagopar1134100000.5", "cd48c61fdd774e58354eafe309fbb2c1d64379e4"},
+		{"pedidos/1.1/traer", queryToken(privateKey), "sk_test_pagoparCONSULTA", "9d1f108d8a1750a6180a5e3935eeb134e758fe28"},
+		{"forma-pago/1.1/traer", paymentMethodsToken(privateKey), "sk_test_pagoparFORMA-PAGO", "e7610036cf0465f3232cbf7c24828840f45ab17f"},
+		{"webhook", webhookToken(privateKey, hash), "sk_test_pagopar" + hash, "eafc7bd352c32a1477bf455a8cbd2cecb597c5e8"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %s, want sha1(%q) = %s", tt.name, tt.got, tt.preimage, tt.want)
+		}
+	}
+}
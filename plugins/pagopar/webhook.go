@@ -0,0 +1,44 @@
+package pagopar
+
+import (
+	"encoding/json"
+	"payment-emulator/internal/store"
+	"payment-emulator/internal/webhook"
+)
+
+// Eventos de webhook de Pagopar
+const (
+	WebhookEventPayment    = "pagopar.pago"
+	WebhookEventReversal   = "pagopar.reversion"
+	WebhookEventExpiration = "pagopar.vencimiento"
+)
+
+// notifyMerchant encola el webhook de Pagopar hacia la url_respuesta del pedido
+func (p *PagoparPlugin) notifyMerchant(tx *store.Transaction, event string) *webhook.Delivery {
+	webhookURL := tx.Meta(MetaUrlRespuesta)
+	if webhookURL == "" {
+		// Sin url_respuesta en el pedido se usa la URL configurada para el comercio
+		if merchant, ok := p.config.FindMerchant(tx.Meta(MetaPublicKey)); ok {
+			webhookURL = merchant.WebhookURL
+		}
+	}
+	if webhookURL == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(PagoparWebhookData{
+		Resultado: []PagoparOrderStatusData{p.buildOrderStatus(tx)},
+		Respuesta: true,
+	})
+	if err != nil {
+		return nil
+	}
+
+	return p.webhooks.Enqueue(webhook.Request{
+		Gateway:       GatewayName,
+		Event:         event,
+		TransactionID: tx.ID,
+		URL:           webhookURL,
+		Body:          body,
+	})
+}
@@ -0,0 +1,72 @@
+package pagopar
+
+import (
+	"net/http"
+	"payment-emulator/internal/store"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// webhookRequest arma una confirmación o reversión firmada por el comercio
+func webhookRequest(publicKey, privateKey, hash string) gin.H {
+	return gin.H{
+		"hash_pedido":   hash,
+		"token":         webhookToken(privateKey, hash),
+		"token_publico": publicKey,
+	}
+}
+
+func TestWebhookConfirmRequiresMerchantToken(t *testing.T) {
+	s := newTestServer(t)
+	hash := s.createOrder(merchantA, "1134", 100000)
+
+	tests := []struct {
+		name string
+		body gin.H
+		code int
+	}{
+		{"sin campos", gin.H{}, http.StatusBadRequest},
+		{"solo hash_pedido", gin.H{"hash_pedido": hash}, http.StatusBadRequest},
+		{"token inválido", webhookRequest(merchantA.PublicKey, "otra_clave", hash), http.StatusUnauthorized},
+		{"pedido de otro comercio", webhookRequest(merchantB.PublicKey, merchantB.PrivateKey, hash), http.StatusNotFound},
+		{"hash inexistente", webhookRequest(merchantA.PublicKey, merchantA.PrivateKey, "no-existe"), http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		var response PagoparErrorResponse
+		if code := s.do(http.MethodPost, "/api/webhook/confirm", tt.body, &response); code != tt.code || response.Respuesta {
+			t.Errorf("%s: webhook/confirm = %d %+v, want %d", tt.name, code, response, tt.code)
+		}
+	}
+
+	if tx := s.order(hash); tx.Status != store.StatusCreated {
+		t.Fatalf("rejected confirmations moved the order to %s", tx.Status)
+	}
+}
+
+func TestWebhookConfirmAndReversal(t *testing.T) {
+	s := newTestServer(t)
+	hash := s.createOrder(merchantA, "1134", 100000)
+
+	var confirmed PagoparWebhookData
+	if code := s.do(http.MethodPost, "/api/webhook/confirm", webhookRequest(merchantA.PublicKey, merchantA.PrivateKey, hash), &confirmed); code != http.StatusOK {
+		t.Fatalf("webhook/confirm = %d, want 200", code)
+	}
+	if !confirmed.Resultado[0].Pagado || s.order(hash).Status != store.StatusPaid {
+		t.Fatalf("webhook/confirm left the order %s (pagado %v), want paid", s.order(hash).Status, confirmed.Resultado[0].Pagado)
+	}
+
+	// Un pedido de otro comercio no puede revertirse
+	if code := s.do(http.MethodPost, "/api/webhook/reversal", webhookRequest(merchantB.PublicKey, merchantB.PrivateKey, hash), nil); code != http.StatusNotFound {
+		t.Errorf("webhook/reversal by another merchant = %d, want 404", code)
+	}
+
+	var reversed PagoparWebhookData
+	if code := s.do(http.MethodPost, "/api/webhook/reversal", webhookRequest(merchantA.PublicKey, merchantA.PrivateKey, hash), &reversed); code != http.StatusOK {
+		t.Fatalf("webhook/reversal = %d, want 200", code)
+	}
+	if tx := s.order(hash); tx.Status != store.StatusReversed {
+		t.Errorf("webhook/reversal left the order %s, want reversed", tx.Status)
+	}
+}